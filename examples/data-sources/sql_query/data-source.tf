@@ -10,4 +10,43 @@ locals {
 
 output "math" {
   value = local.math
-}
\ No newline at end of file
+}
+
+# bind values instead of interpolating them in to the query
+data "sql_query" "users" {
+  query      = "select id, name from users where name = :name and active = :active"
+  parameters = {
+    name   = "alice"
+    active = true
+  }
+}
+
+# pin the type of columns that differ across databases
+data "sql_query" "events" {
+  query = "select id, created_on, year(created_on) as created_year from events"
+
+  column_types = {
+    created_on   = "string"
+    created_year = "number"
+  }
+}
+
+# key the rows by a unique column for use in for_each
+data "sql_query" "databases" {
+  query      = "select name, owner from databases"
+  key_column = "name"
+}
+
+output "database_owners" {
+  value = { for name, db in data.sql_query.databases.result_map : name => db.owner }
+}
+
+# load the query from a file in the module and render values in to it
+data "sql_query" "report" {
+  query_file = "${path.module}/queries/report.sql"
+
+  vars = {
+    table = "analytics.events"
+    kinds = ["click", "view"]
+  }
+}
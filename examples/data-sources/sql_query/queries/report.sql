@@ -0,0 +1,4 @@
+select kind, count(*) as total
+from ${table:identifier}
+where kind in (${kinds})
+group by kind
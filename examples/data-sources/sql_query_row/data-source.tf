@@ -0,0 +1,10 @@
+data "sql_query_row" "user" {
+  query      = "select id, name, email from users where name = :name"
+  parameters = {
+    name = "alice"
+  }
+}
+
+output "email" {
+  value = data.sql_query_row.user.result.email
+}
@@ -0,0 +1,7 @@
+data "sql_query_value" "user_count" {
+  query = "select count(*) from users"
+}
+
+output "user_count" {
+  value = data.sql_query_value.user_count.result
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// binaryEncodingBase64 returns binary columns as standard base64 strings.
+	binaryEncodingBase64 = "base64"
+	// binaryEncodingHex returns binary columns as lower case hex strings.
+	binaryEncodingHex = "hex"
+)
+
+// sqlBinary scans a binary value (BYTEA, VARBINARY, BLOB, etc) and returns it base64 encoded.
+type sqlBinary struct {
+	value []byte
+	valid bool
+}
+
+func (b *sqlBinary) Scan(v interface{}) error {
+	switch vt := v.(type) {
+	case nil:
+		b.value, b.valid = nil, false
+		return nil
+	case []byte:
+		// the driver may reuse the buffer, so it must be copied
+		b.value, b.valid = append([]byte{}, vt...), true
+		return nil
+	case string:
+		b.value, b.valid = []byte(vt), true
+		return nil
+	}
+
+	return fmt.Errorf("cannot convert %T to binary", v)
+}
+
+func (b *sqlBinary) ToTerraform5Value() (interface{}, error) {
+	if b == nil || !b.valid {
+		return nil, nil
+	}
+	s := base64.StdEncoding.EncodeToString(b.value)
+	return &s, nil
+}
+
+// sqlBinaryHex scans a binary value and returns it hex encoded.
+type sqlBinaryHex struct {
+	sqlBinary
+}
+
+func (b *sqlBinaryHex) ToTerraform5Value() (interface{}, error) {
+	if b == nil || !b.valid {
+		return nil, nil
+	}
+	s := hex.EncodeToString(b.value)
+	return &s, nil
+}
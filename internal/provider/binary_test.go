@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestSQLBinary(t *testing.T) {
+	value := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	b := &sqlBinary{}
+	err := b.Scan(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the driver may reuse the buffer
+	value[0] = 0
+	v, err := b.ToTerraform5Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := *v.(*string); s != "3q2+7w==" {
+		t.Fatalf("expected base64, got %q", s)
+	}
+
+	h := &sqlBinaryHex{}
+	err = h.Scan([]byte{0xde, 0xad, 0xbe, 0xef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = h.ToTerraform5Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := *v.(*string); s != "deadbeef" {
+		t.Fatalf("expected hex, got %q", s)
+	}
+
+	err = h.Scan(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = h.ToTerraform5Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Fatalf("expected null, got %v", v)
+	}
+}
+
+func TestMySQLBit(t *testing.T) {
+	b := &mysqlBit{}
+	err := b.Scan([]byte{0x01, 0x02})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := b.value.Uint64(); n != 258 {
+		t.Fatalf("expected 258, got %d", n)
+	}
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/denisenkom/go-mssqldb/msdsn"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"modernc.org/sqlite"
+)
+
+// connector builds a driver.Connector for the data source name, applying any provider level
+// connection settings (TLS, SSH tunnel, password command, session initialization, etc) in the way each driver expects.
+func (p *provider) connector(name driverName, dsn string) (driver.Connector, error) {
+	// build the connector once to validate the configuration
+	var password *string
+	if p.passwordCommand != nil {
+		password = new(string)
+	}
+	connector, err := p.driverConnector(name, dsn, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.passwordCommand != nil {
+		connector = &credentialConnector{
+			command: p.passwordCommand,
+			connector: func(password string) (driver.Connector, error) {
+				return p.driverConnector(name, dsn, &password)
+			},
+			// building a connector has side effects for some drivers (ie. registering the TLS
+			// config for mysql), so the driver of the validated connector is reused
+			driver: connector.Driver(),
+		}
+	}
+
+	if len(p.sessionInit) > 0 {
+		connector = &sessionInitConnector{
+			Connector:  connector,
+			statements: p.sessionInit,
+			// the driver resets the session when a pooled connection is reused
+			resetClearsSession: name == driverSQLServer,
+		}
+	}
+
+	return connector, nil
+}
+
+// driverConnector builds the driver specific connector, password overrides the password in
+// the data source name if not nil.
+func (p *provider) driverConnector(name driverName, dsn string, password *string) (driver.Connector, error) {
+	tlsConfig, err := p.tls.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case driverPGX:
+		cfg, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, err
+		}
+		if password != nil {
+			cfg.Password = *password
+		}
+		if tlsConfig != nil {
+			cfg.TLSConfig = serverTLSConfig(tlsConfig, cfg.Host)
+			// fallbacks are used for sslmode=prefer, etc, but TLS has been explicitly configured
+			cfg.Fallbacks = nil
+		}
+		if p.sshTunnel != nil {
+			cfg.DialFunc = p.sshTunnel.DialContext
+			// the host is resolved by the SSH host, not locally
+			cfg.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+				return []string{host}, nil
+			}
+		}
+		return stdlib.GetConnector(*cfg, stdlib.OptionAfterConnect(pgTypesAsText)), nil
+	case driverMySQL:
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		if password != nil {
+			cfg.Passwd = *password
+		}
+		// TLS configs and dialers are registered per provider instance, reconfiguring replaces them
+		key := fmt.Sprintf("terraform-provider-sql-%p", p)
+		if tlsConfig != nil {
+			host, _, err := net.SplitHostPort(cfg.Addr)
+			if err != nil {
+				host = cfg.Addr
+			}
+			err = mysql.RegisterTLSConfig(key, serverTLSConfig(tlsConfig, host))
+			if err != nil {
+				return nil, err
+			}
+			cfg.TLSConfig = key
+			// re-parse to resolve the registered TLS config
+			cfg, err = mysql.ParseDSN(cfg.FormatDSN())
+			if err != nil {
+				return nil, err
+			}
+		}
+		if p.sshTunnel != nil {
+			tunnel := p.sshTunnel
+			mysql.RegisterDialContext(key, func(ctx context.Context, addr string) (net.Conn, error) {
+				return tunnel.DialContext(ctx, "tcp", addr)
+			})
+			// the driver only defaults the port for the tcp network
+			if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+				cfg.Addr = net.JoinHostPort(cfg.Addr, "3306")
+			}
+			cfg.Net = key
+		}
+		return mysql.NewConnector(cfg)
+	case driverSQLServer:
+		cfg, _, err := msdsn.Parse(dsn)
+		if err != nil {
+			return nil, err
+		}
+		if password != nil {
+			cfg.Password = *password
+		}
+		if tlsConfig != nil {
+			cfg.TLSConfig = serverTLSConfig(tlsConfig, cfg.Host)
+			cfg.HostInCertificateProvided = tlsConfig.ServerName != ""
+			cfg.Encryption = msdsn.EncryptionRequired
+		}
+		connector := mssql.NewConnectorConfig(cfg)
+		if p.sshTunnel != nil {
+			connector.Dialer = p.sshTunnel
+		}
+		return connector, nil
+	case driverSQLite:
+		if tlsConfig != nil {
+			return nil, fmt.Errorf("TLS is not supported by the %s driver", name)
+		}
+		if p.sshTunnel != nil {
+			return nil, fmt.Errorf("SSH tunnels are not supported by the %s driver", name)
+		}
+		if password != nil {
+			return nil, fmt.Errorf("password_command is not supported by the %s driver", name)
+		}
+		return &dsnConnector{
+			dsn:    dsn,
+			driver: &sqlite.Driver{},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected driver: %q", name)
+}
+
+// dsnConnector is a driver.Connector for drivers that do not implement driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+var _ driver.Connector = (*dsnConnector)(nil)
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+// maxPasswordExpiryWindow is the longest the password is refreshed before it expires, so a
+// password does not expire while a connection is being established.
+const maxPasswordExpiryWindow = 30 * time.Second
+
+// passwordExpiryWindow returns how long before it expires a password with the lifetime is
+// refreshed, a quarter of the lifetime up to maxPasswordExpiryWindow so short lived passwords
+// are still cached for most of their lifetime.
+func passwordExpiryWindow(lifetime time.Duration) time.Duration {
+	window := lifetime / 4
+	switch {
+	case window < 0:
+		return 0
+	case window > maxPasswordExpiryWindow:
+		return maxPasswordExpiryWindow
+	}
+	return window
+}
+
+// passwordCommand runs an external command to obtain the database password. The command
+// should write a JSON document to stdout:
+//
+//	{"password": "...", "expires_at": "2006-01-02T15:04:05Z"}
+//
+// `expires_at` is optional, if omitted the password is cached until an authentication failure.
+type passwordCommand struct {
+	Args []string
+}
+
+type passwordCommandOutput struct {
+	Password  string     `json:"password"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (c *passwordCommand) Run(ctx context.Context) (*passwordCommandOutput, error) {
+	if len(c.Args) == 0 {
+		return nil, fmt.Errorf("password_command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("unable to run password_command %q: %w: %s", c.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	out := &passwordCommandOutput{}
+	err = json.Unmarshal(stdout.Bytes(), out)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse password_command output: %w", err)
+	}
+
+	if out.Password == "" {
+		return nil, fmt.Errorf("password_command output did not include a password")
+	}
+
+	return out, nil
+}
+
+// credentialConnector obtains the password from a password command for each new connection,
+// running the command again when the password expires or the driver reports an
+// authentication failure.
+type credentialConnector struct {
+	command *passwordCommand
+	// connector builds a driver connector using the password
+	connector func(password string) (driver.Connector, error)
+	// driver is the driver of the connectors, it does not depend on the password
+	driver driver.Driver
+
+	mu      sync.Mutex
+	current driver.Connector
+	// refreshAt is when the password is refreshed, before it expires
+	refreshAt *time.Time
+}
+
+var _ driver.Connector = (*credentialConnector)(nil)
+
+func (c *credentialConnector) connectorFor(ctx context.Context, refresh bool) (driver.Connector, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expired := c.refreshAt != nil && !time.Now().Before(*c.refreshAt)
+	if c.current != nil && !refresh && !expired {
+		return c.current, nil
+	}
+
+	out, err := c.command.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := c.connector(out.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	c.current = current
+	c.refreshAt = nil
+	if out.ExpiresAt != nil {
+		refreshAt := out.ExpiresAt.Add(-passwordExpiryWindow(time.Until(*out.ExpiresAt)))
+		c.refreshAt = &refreshAt
+	}
+	return c.current, nil
+}
+
+func (c *credentialConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := c.connectorFor(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := connector.Connect(ctx)
+	if err == nil || !isAuthError(err) {
+		return conn, err
+	}
+
+	// the password may have been rotated, refresh it and try once more
+	connector, err = c.connectorFor(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return connector.Connect(ctx)
+}
+
+func (c *credentialConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// isAuthError determines if the error is a driver specific authentication failure.
+func isAuthError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// invalid_authorization_specification, invalid_password
+		return pgErr.Code == "28000" || pgErr.Code == "28P01"
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_ACCESS_DENIED_ERROR
+		return mysqlErr.Number == 1045
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		// login failed
+		return mssqlErr.Number == 18456
+	}
+
+	return false
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const testPasswordCommandOutputEnv = "TF_SQL_TEST_PASSWORD_COMMAND_OUTPUT"
+
+// TestHelperPasswordCommand is not a real test, it is invoked as the password command
+// by testPasswordCommand.
+func TestHelperPasswordCommand(t *testing.T) {
+	out, ok := os.LookupEnv(testPasswordCommandOutputEnv)
+	if !ok {
+		return
+	}
+	fmt.Fprint(os.Stdout, out)
+	os.Exit(0)
+}
+
+func testPasswordCommand(t *testing.T, output string) *passwordCommand {
+	t.Setenv(testPasswordCommandOutputEnv, output)
+	return &passwordCommand{
+		Args: []string{os.Args[0], "-test.run=TestHelperPasswordCommand"},
+	}
+}
+
+type testPasswordConnector struct {
+	password string
+	valid    *string
+	connects *int
+}
+
+func (c *testPasswordConnector) Connect(context.Context) (driver.Conn, error) {
+	*c.connects++
+	if c.password != *c.valid {
+		return nil, &mysql.MySQLError{Number: 1045, Message: "Access denied"}
+	}
+	return nil, nil
+}
+
+func (c *testPasswordConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestPasswordCommand(t *testing.T) {
+	for name, c := range map[string]struct {
+		output    string
+		expected  string
+		expires   bool
+		expectErr bool
+	}{
+		"password":    {`{"password": "secret"}`, "secret", false, false},
+		"expires":     {`{"password": "secret", "expires_at": "2030-01-02T15:04:05Z"}`, "secret", true, false},
+		"no password": {`{}`, "", false, true},
+		"invalid":     {`not json`, "", false, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := testPasswordCommand(t, c.output).Run(context.Background())
+			if c.expectErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Password != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, out.Password)
+			}
+			if (out.ExpiresAt != nil) != c.expires {
+				t.Fatalf("unexpected expires_at %v", out.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestCredentialConnector(t *testing.T) {
+	valid := "old"
+	connects := 0
+
+	c := &credentialConnector{
+		command: testPasswordCommand(t, `{"password": "old"}`),
+		connector: func(password string) (driver.Connector, error) {
+			return &testPasswordConnector{
+				password: password,
+				valid:    &valid,
+				connects: &connects,
+			}, nil
+		},
+	}
+
+	_, err := c.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate the password, the cached password should fail and be refreshed
+	valid = "new"
+	t.Setenv(testPasswordCommandOutputEnv, `{"password": "new"}`)
+
+	_, err = c.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connects != 3 {
+		t.Fatalf("expected 3 connection attempts, got %d", connects)
+	}
+
+	// an expired password is refreshed before connecting
+	t.Setenv(testPasswordCommandOutputEnv, `{"password": "new", "expires_at": "2000-01-01T00:00:00Z"}`)
+	_, err = c.connectorFor(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid = "newer"
+	t.Setenv(testPasswordCommandOutputEnv, `{"password": "newer"}`)
+
+	_, err = c.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connects != 4 {
+		t.Fatalf("expected 4 connection attempts, got %d", connects)
+	}
+
+	// failures other than authentication are not retried
+	valid = "other"
+	t.Setenv(testPasswordCommandOutputEnv, `{"password": "newer"}`)
+	_, err = c.Connect(context.Background())
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+}
+
+func TestCredentialConnector_shortExpiry(t *testing.T) {
+	builds := 0
+	c := &credentialConnector{
+		command: testPasswordCommand(t, fmt.Sprintf(`{"password": "short", "expires_at": %q}`,
+			time.Now().Add(20*time.Second).UTC().Format(time.RFC3339Nano))),
+		connector: func(password string) (driver.Connector, error) {
+			builds++
+			return &testPasswordConnector{}, nil
+		},
+	}
+
+	// a password that expires within the maximum window is still cached
+	for i := 0; i < 3; i++ {
+		_, err := c.connectorFor(context.Background(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if builds != 1 {
+		t.Fatalf("expected the password command to run once, ran %d times", builds)
+	}
+}
+
+func TestPasswordExpiryWindow(t *testing.T) {
+	for lifetime, expected := range map[time.Duration]time.Duration{
+		-time.Second:     0,
+		20 * time.Second: 5 * time.Second,
+		time.Hour:        maxPasswordExpiryWindow,
+	} {
+		if actual := passwordExpiryWindow(lifetime); actual != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, lifetime, actual)
+		}
+	}
+}
+
+func TestCredentialConnector_driver(t *testing.T) {
+	drv := &mysql.MySQLDriver{}
+	c := &credentialConnector{
+		command: testPasswordCommand(t, `{"password": "old"}`),
+		connector: func(password string) (driver.Connector, error) {
+			t.Fatal("the connector should not be built to get the driver")
+			return nil, nil
+		},
+		driver: drv,
+	}
+
+	if actual := c.Driver(); actual != drv {
+		t.Fatalf("expected %#v, got %#v", drv, actual)
+	}
+}
@@ -30,7 +30,7 @@ func (d *dataDriver) Schema(context.Context) *tfprotov6.Schema {
 				{
 					Name:            "name",
 					Computed:        true,
-					Description:     "The name of the driver, currently this will be one of `pgx`, `mysql`, or `sqlserver`.",
+					Description:     "The name of the driver, currently this will be one of `pgx`, `mysql`, `sqlserver`, or `sqlite`.",
 					DescriptionKind: tfprotov6.StringKindMarkdown,
 					Type:            tftypes.String,
 				},
@@ -46,6 +46,15 @@ func (d *dataDriver) Validate(ctx context.Context, config map[string]tftypes.Val
 }
 
 func (d *dataDriver) Read(ctx context.Context, config map[string]tftypes.Value) (map[string]tftypes.Value, []*tfprotov6.Diagnostic, error) {
+	if d.driver == "" {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "The driver cannot be determined until the provider connection configuration is known.",
+			},
+		}, nil
+	}
+
 	return map[string]tftypes.Value{
 		"name": tftypes.NewValue(
 			tftypes.String,
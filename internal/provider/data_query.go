@@ -2,7 +2,13 @@ package provider
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -46,65 +52,896 @@ func (d *dataQuery) Schema(context.Context) *tfprotov6.Schema {
 		Block: &tfprotov6.SchemaBlock{
 			Description:     "The `sql_query` datasource allows you to execute a SQL query against the database of your choice.",
 			DescriptionKind: tfprotov6.StringKindMarkdown,
-			Attributes: []*tfprotov6.SchemaAttribute{
+			Attributes: append(queryAttributes("result"), []*tfprotov6.SchemaAttribute{
 				{
-					Name:            "query",
-					Required:        true,
-					Description:     "The query to execute. The types in this query will be reflected in the typing of the `result` attribute.",
+					Name:     "key_column",
+					Optional: true,
+					Description: "The name of a column whose values are unique, the rows are then also returned in " +
+						"`result_map` keyed by the value of this column. Duplicate or null values are an error.",
 					DescriptionKind: tfprotov6.StringKindMarkdown,
 					Type:            tftypes.String,
 				},
-				// {
-				// 	Name:            "parameters",
-				// 	Optional:        true,
-				// 	DescriptionKind: tfprotov6.StringKindMarkdown,
-				// 	Type:            tftypes.DynamicPseudoType,
-				// },
+				{
+					Name:     "max_rows",
+					Optional: true,
+					Description: "Limits the number of rows in each result set of the query, to guard against queries " +
+						"that return more rows than expected. What happens when the limit is exceeded is set by " +
+						"`on_limit`. Default is unlimited.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.Number,
+				},
+				{
+					Name:     "on_limit",
+					Optional: true,
+					Description: "What happens when the result exceeds `max_rows` or the provider's `max_result_bytes`. " +
+						"`error` fails the query, `truncate` returns the rows up to the limit with a warning and sets " +
+						"`truncated`. Default is `error`.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.String,
+				},
+				{
+					Name:            "truncated",
+					Computed:        true,
+					Description:     "Whether the result was truncated because it exceeded a limit, see `on_limit`.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.Bool,
+				},
 
 				{
 					Name:     "result",
 					Computed: true,
 					Description: "The result of the query. This will be a list of objects. Each object will have attributes " +
 						"with names that match column names and types that match column types. The exact translation of types " +
-						"is dependent upon the database driver.",
+						"is dependent upon the database driver. PostgreSQL arrays are returned as lists (nested lists for " +
+						"multi-dimensional arrays). PostgreSQL does not report the dimensions of an array column, so " +
+						"arrays are nested to the most dimensions of any array in the column (arrays with fewer dimensions " +
+						"are wrapped in single element lists) and a column with only empty or null arrays is a list. " +
+						"Numbers that are NaN or infinite cannot be represented in Terraform and are returned as null.",
 					DescriptionKind: tfprotov6.StringKindMarkdown,
 					Type: tftypes.List{
 						ElementType: tftypes.DynamicPseudoType,
 					},
 				},
+				{
+					Name:     "result_map",
+					Computed: true,
+					Description: "The rows of `result` as a map keyed by the value of `key_column` (converted to a string), " +
+						"for use in `for_each`. Null if `key_column` is not set.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type: tftypes.Map{
+						ElementType: tftypes.DynamicPseudoType,
+					},
+				},
+				{
+					Name:     "results",
+					Computed: true,
+					Description: "The results of all the result sets of the query, for stored procedures or batches of " +
+						"statements that return more than one. This is a list with an element per result set, each a list " +
+						"of objects the same as `result`. Each result set is typed independently. `result` is the first " +
+						"result set.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.DynamicPseudoType,
+				},
+				{
+					Name:     "columns",
+					Computed: true,
+					Description: "The columns of `result` (the first result set) in order. Each object has the `position` (starting at `1`), " +
+						"`name` (as used in `result`), `database_type` (the type name reported by the database driver, " +
+						"ie. `VARCHAR`), `nullable`, `length` (for variable length types), and `precision` and `scale` (for " +
+						"decimal types) of the column. Attributes the driver does not report are null.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type: tftypes.List{
+						ElementType: columnMetadataType,
+					},
+				},
 
 				deprecatedIDAttribute(),
-			},
+			}...),
+		},
+	}
+}
+
+// queryAttributes are the attributes shared by the query data sources, see dataQuery.run.
+func queryAttributes(resultAttribute string) []*tfprotov6.SchemaAttribute {
+	return []*tfprotov6.SchemaAttribute{
+		{
+			Name:     "query",
+			Optional: true,
+			Description: "The query to execute. The types in this query will be reflected in the typing of the `" +
+				resultAttribute + "` attribute. Exactly one of `query` or `query_file` is required.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.String,
+		},
+		{
+			Name:     "query_file",
+			Optional: true,
+			Description: "The path of a file containing the query to execute, instead of `query`. Relative paths " +
+				"are relative to the working directory of Terraform, use `path.module` to reference a file in the " +
+				"module (ie. `\"${path.module}/queries/users.sql\"`).",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.String,
+		},
+		{
+			Name:     "vars",
+			Optional: true,
+			Description: "Values to render in to the query, referenced as `${name}`. Unlike `parameters`, these are " +
+				"rendered in to the SQL text, so they can be used where the database does not support placeholders. " +
+				"Values are quoted as literals for the driver: strings are quoted and escaped (for MySQL backslashes " +
+				"are escaped unless the `NO_BACKSLASH_ESCAPES` SQL mode of the session is enabled), numbers and bools " +
+				"are rendered as is (negative numbers in parentheses) and lists are rendered as a comma separated " +
+				"list of literals (ie. for `IN (${ids})`). " +
+				"Use `${name:identifier}` to quote a string as an identifier instead (ie. a table name, `schema.table` " +
+				"is quoted per part). `$${` renders a literal `${`. Placeholders are only rendered when `vars` is set, " +
+				"note that `${...}` in a `query` string is interpolated by Terraform, so use `$${name}` there.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.DynamicPseudoType,
+		},
+		{
+			Name:     "parameters",
+			Optional: true,
+			Description: "Values to bind to placeholders in the query, instead of interpolating them in to the SQL. " +
+				"A list is bound by position and referenced as `$1`, `$2`, etc, an object is bound by name and " +
+				"referenced as `:name`. These placeholders are translated to the driver's syntax (`$1` for " +
+				"`postgres`, `?` for `mysql`, `@p1` for `sqlserver`) so the same query works on all drivers. " +
+				"If the query contains none of these placeholders, a list is passed unchanged so the driver's " +
+				"native placeholders can be used. Only strings, numbers, bools and nulls can be bound, whole numbers " +
+				"outside of the 64-bit integer range are bound as strings so they do not lose precision.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.DynamicPseudoType,
+		},
+		{
+			Name:     "column_types",
+			Optional: true,
+			Description: "Overrides the type of columns in the result, by column name, instead of using the " +
+				"type of the column reported by the database driver. One of `string`, `number`, `bool`, `json` " +
+				"(decoded the same as `decode_json`) or `base64` (binary data encoded as base64). Values are " +
+				"converted by the driver, so this can be used to get consistent types across databases, for " +
+				"example a `year` that is a number for `mysql` and a string elsewhere. `sqlite` columns without a " +
+				"declared type are strings unless the query always returns a number for them (ie. arithmetic or " +
+				"`count(*)`), `max(id)` for example is a string.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.Map{ElementType: tftypes.String},
+		},
+		{
+			Name:     "duplicate_columns",
+			Optional: true,
+			Description: "How columns with the same name in the result (ie. `id` from both tables of a join) are " +
+				"handled. `rename` suffixes the later columns with their occurrence (`id`, `id_2`, `id_3`), `error` " +
+				"fails the query. Columns without a name are named by their position (`column0`). Default is `rename`.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.String,
+		},
+		{
+			Name:     "validate_on_plan",
+			Optional: true,
+			Description: "Prepare the query before it is run, so syntax errors and unknown tables or columns are " +
+				"reported on `query`, including the position of the error reported by the database. Only queries " +
+				"with a single statement are validated, a batch of statements separated by `;` is run without " +
+				"validating it (with a warning) as its statements may depend on the ones before them (ie. a table " +
+				"created by the batch). Terraform reads a data source during plan when its configuration is known, so the errors are " +
+				"reported during plan. A read that Terraform defers to apply (ie. when `parameters` or `vars` are " +
+				"unknown) is only validated at apply, as Terraform does not call the provider for it during plan. " +
+				"`sqlite` queries are compiled with `EXPLAIN` and `sqlserver` queries with " +
+				"`sp_describe_first_result_set`. Default is `false`.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.Bool,
+		},
+		{
+			Name:     "decode_json",
+			Optional: true,
+			Description: "Decode JSON columns (`json` and `jsonb` for `postgres`, `json` for `mysql`, columns declared " +
+				"as `json` for `sqlite`) in to Terraform objects, lists, numbers, bools and strings instead of " +
+				"returning the JSON as a string. Arrays whose elements differ in type are decoded as tuples. " +
+				"Documents that differ in shape across rows are combined in to a single type, missing object " +
+				"attributes are null. Defaults to the provider's `decode_json` setting.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Type:            tftypes.Bool,
 		},
 	}
 }
 
 func (d *dataQuery) Validate(ctx context.Context, config map[string]tftypes.Value) ([]*tfprotov6.Diagnostic, error) {
-	// TODO: if connected to server, validate query against it?
-	return nil, nil
+	diags, err := validateQueryAttributes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	limitDiags, err := validateOneOf(config, "on_limit", []string{onLimitError, onLimitTruncate})
+	if err != nil {
+		return nil, err
+	}
+	diags = append(diags, limitDiags...)
+
+	if v := config["max_rows"]; !v.IsNull() && v.IsKnown() {
+		maxRows := &big.Float{}
+		err = v.As(&maxRows)
+		if err != nil {
+			return nil, err
+		}
+		if n, acc := maxRows.Int64(); acc != big.Exact || n < 1 {
+			diags = append(diags, &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("max_rows"),
+				}),
+				Summary: "`max_rows` must be a whole number greater than zero.",
+			})
+		}
+	}
+
+	return diags, nil
+}
+
+// validateQueryAttributes validates the attributes from queryAttributes.
+func validateQueryAttributes(config map[string]tftypes.Value) ([]*tfprotov6.Diagnostic, error) {
+	diags, err := validateOneOf(config, "duplicate_columns", []string{duplicateColumnsRename, duplicateColumnsError})
+	if err != nil {
+		return nil, err
+	}
+
+	query, queryFile := config["query"], config["query_file"]
+	if query.IsKnown() && queryFile.IsKnown() && query.IsNull() == queryFile.IsNull() {
+		diags = append(diags, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+				tftypes.AttributeName("query"),
+			}),
+			Summary: "Exactly one of `query` or `query_file` is required.",
+		})
+	}
+
+	if v := config["vars"]; !v.IsNull() && v.IsKnown() {
+		if _, err := varsFromValue(v); err != nil {
+			diags = append(diags, &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("vars"),
+				}),
+				Summary: fmt.Sprintf("Unable to read vars: %s", err),
+			})
+		}
+	}
+
+	if v := config["column_types"]; !v.IsNull() && v.IsKnown() {
+		var types map[string]tftypes.Value
+		err = v.As(&types)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, tv := range types {
+			if tv.IsNull() || !tv.IsKnown() {
+				continue
+			}
+			var ty string
+			err = tv.As(&ty)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := columnForOverride(name, ty); err == nil {
+				continue
+			}
+			diags = append(diags, &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("column_types"),
+					tftypes.ElementKeyString(name),
+				}),
+				Summary: fmt.Sprintf("Unsupported column type %q, expected one of `%s`.", ty, strings.Join(columnTypes, "`, `")),
+			})
+		}
+	}
+
+	return diags, nil
 }
 
 func (d *dataQuery) Read(ctx context.Context, config map[string]tftypes.Value) (map[string]tftypes.Value, []*tfprotov6.Diagnostic, error) {
-	var (
-		query string
-	)
-	err := config["query"].As(&query)
+	var err error
+
+	limits := &resultLimits{
+		maxBytes: d.p.maxResultBytes,
+		onLimit:  true,
+	}
+	if v := config["max_rows"]; !v.IsNull() {
+		maxRows := &big.Float{}
+		err = v.As(&maxRows)
+		if err != nil {
+			return nil, nil, err
+		}
+		limits.maxRows, _ = maxRows.Int64()
+	}
+	if v := config["on_limit"]; !v.IsNull() {
+		var onLimit string
+		err = v.As(&onLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+		limits.truncate = onLimit == onLimitTruncate
+	}
+
+	sets, diags, err := d.run(ctx, config, limits)
+	if err != nil || sets == nil {
+		return nil, diags, err
+	}
+
+	resultMap := tftypes.NewValue(tftypes.Map{ElementType: objectTypeForColumns(sets[0].columns)}, nil)
+	if v := config["key_column"]; !v.IsNull() {
+		var keyColumn string
+		err = v.As(&keyColumn)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var keyDiags []*tfprotov6.Diagnostic
+		resultMap, keyDiags, err = resultMapForKey(sets[0], keyColumn)
+		if err != nil {
+			return nil, nil, err
+		}
+		if keyDiags != nil {
+			return nil, append(diags, keyDiags...), nil
+		}
+	}
+
+	// each result set is typed independently, so the sets are a tuple
+	resultTypes := make([]tftypes.Type, 0, len(sets))
+	results := make([]tftypes.Value, 0, len(sets))
+	for _, set := range sets {
+		resultTypes = append(resultTypes, set.result.Type())
+		results = append(results, set.result)
+	}
+
+	return map[string]tftypes.Value{
+		"id":                queryID(config),
+		"query":             config["query"],
+		"query_file":        config["query_file"],
+		"vars":              config["vars"],
+		"validate_on_plan":  config["validate_on_plan"],
+		"parameters":        config["parameters"],
+		"decode_json":       config["decode_json"],
+		"column_types":      config["column_types"],
+		"duplicate_columns": config["duplicate_columns"],
+		"result":            sets[0].result,
+		"key_column":        config["key_column"],
+		"result_map":        resultMap,
+		"results":           tftypes.NewValue(tftypes.Tuple{ElementTypes: resultTypes}, results),
+		"columns":           columnsMetadataValue(sets[0].columns),
+		"max_rows":          config["max_rows"],
+		"on_limit":          config["on_limit"],
+		"truncated":         tftypes.NewValue(tftypes.Bool, limits.truncated),
+	}, diags, nil
+}
+
+// queryID returns the id of a query data source, the query or the path of the query file.
+func queryID(config map[string]tftypes.Value) tftypes.Value {
+	if v := config["query"]; !v.IsNull() {
+		return v
+	}
+	return config["query_file"]
+}
+
+// loadQuery reads the query from `query` or `query_file` and renders the `vars` in to it.
+func (d *dataQuery) loadQuery(ctx context.Context, config map[string]tftypes.Value) (string, []*tfprotov6.Diagnostic, error) {
+	var query string
+	if v := config["query"]; !v.IsNull() {
+		err := v.As(&query)
+		if err != nil {
+			return "", nil, err
+		}
+	} else {
+		var queryFile string
+		err := config["query_file"].As(&queryFile)
+		if err != nil {
+			return "", nil, err
+		}
+		b, err := os.ReadFile(queryFile)
+		if err != nil {
+			return "", []*tfprotov6.Diagnostic{
+				{
+					Severity: tfprotov6.DiagnosticSeverityError,
+					Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+						tftypes.AttributeName("query_file"),
+					}),
+					Summary: fmt.Sprintf("Unable to read query file: %s", err),
+				},
+			}, nil
+		}
+		query = string(b)
+	}
+
+	vars, err := varsFromValue(config["vars"])
+	if err != nil {
+		return "", nil, err
+	}
+	if vars == nil {
+		return query, nil, nil
+	}
+
+	dialect := sqlDialect{driver: d.p.Driver}
+	if d.p.Driver == driverMySQL {
+		dialect.noBackslashEscapes, err = mysqlNoBackslashEscapes(ctx, d.db)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	query, err = renderQuery(dialect, query, vars)
+	if err != nil {
+		return "", []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("vars"),
+				}),
+				Summary: fmt.Sprintf("Unable to render query: %s", err),
+			},
+		}, nil
+	}
+	return query, nil, nil
+}
+
+// bindQuery loads the query and binds its parameters for the driver.
+func (d *dataQuery) bindQuery(ctx context.Context, config map[string]tftypes.Value) (string, []interface{}, []*tfprotov6.Diagnostic, error) {
+	query, diags, err := d.loadQuery(ctx, config)
+	if err != nil || diags != nil {
+		return "", nil, diags, err
+	}
+
+	params, err := parametersFromValue(config["parameters"])
+	if err != nil {
+		return "", nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("parameters"),
+				}),
+				Summary: fmt.Sprintf("Unable to read parameters: %s", err),
+			},
+		}, nil
+	}
+
+	query, args, err := bindParameters(d.p.Driver, query, params)
+	if err != nil {
+		return "", nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("query"),
+				}),
+				Summary: fmt.Sprintf("Unable to bind parameters: %s", err),
+			},
+		}, nil
+	}
+	return query, args, nil, nil
+}
+
+// validateQuery prepares the query when `validate_on_plan` is set, before it is run, so errors
+// are reported with their position without running it. A batch of statements is not validated,
+// as the statements may depend on the ones before them having run.
+func (d *dataQuery) validateQuery(ctx context.Context, config map[string]tftypes.Value, query string, args []interface{}) ([]*tfprotov6.Diagnostic, error) {
+	validate := false
+	if v := config["validate_on_plan"]; !v.IsNull() {
+		err := v.As(&validate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !validate {
+		return nil, nil
+	}
+
+	attr := "query"
+	if config["query"].IsNull() {
+		attr = "query_file"
+	}
+
+	if len(splitStatements(d.p.Driver, query)) > 1 {
+		// preparing the statements of a batch before any of them have run reports errors for
+		// objects the batch creates, and most drivers only prepare a single statement
+		return []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityWarning,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName(attr),
+				}),
+				Summary: "Query was not validated",
+				Detail: "The query is a batch of statements, `validate_on_plan` only validates queries with a " +
+					"single statement as the statements of a batch may depend on the ones before them.",
+			},
+		}, nil
+	}
+
+	err := d.prepareQuery(ctx, query, args)
+	if err == nil {
+		return nil, nil
+	}
+	if diags := stoppedDiagnostics(err); diags != nil {
+		return diags, nil
+	}
+
+	diag := &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+			tftypes.AttributeName(attr),
+		}),
+		Summary: fmt.Sprintf("Invalid query: %s", err),
+	}
+	if line, column := queryErrorPosition(query, err); line > 0 {
+		diag.Detail = positionDetail(query, line, column)
+	}
+	return []*tfprotov6.Diagnostic{diag}, nil
+}
+
+// prepareQuery checks the query against the database without executing it, the arguments are
+// only used by drivers that need them to compile the query.
+func (d *dataQuery) prepareQuery(ctx context.Context, query string, args []interface{}) error {
+	switch d.p.Driver {
+	case driverSQLServer:
+		// the driver only prepares the statement when it is executed, the server compiles the
+		// batch to describe its result instead
+		rows, err := d.db.QueryContext(ctx, "sp_describe_first_result_set @tsql = @p1, @params = @p2",
+			query, sqlServerParamsDeclaration(args))
+		if err != nil {
+			return sqlServerDescribeError(err)
+		}
+		return rows.Close()
+	case driverSQLite:
+		// the driver also prepares lazily, explaining the statement compiles it without
+		// executing it
+		rows, err := d.db.QueryContext(ctx, "EXPLAIN "+query, args...)
+		if err != nil {
+			return err
+		}
+		return rows.Close()
+	}
+
+	preparer, ok := d.db.(dbPreparer)
+	if !ok {
+		return nil
+	}
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	return stmt.Close()
+}
+
+// readQueryer returns the read-only transaction the query is run in, unless writes are allowed in
+// data sources. The transaction is rolled back when the read is done.
+func (d *dataQuery) readQueryer(ctx context.Context) (dbQueryer, func(), error) {
+	beginner, ok := d.db.(dbBeginner)
+	if d.p.allowWrites || !ok {
+		return d.db, func() {}, nil
+	}
+
+	tx, err := beginReadOnly(ctx, beginner, d.p.Driver)
 	if err != nil {
 		return nil, nil, err
 	}
+	return tx, func() { _ = tx.Rollback() }, nil
+}
+
+// run executes the query of a query data source and reads all of its result sets. The
+// attributes shared by the query data sources (see queryAttributes) are read from the config.
+// The result sets are nil if there are error diagnostics.
+func (d *dataQuery) run(ctx context.Context, config map[string]tftypes.Value, limits *resultLimits) ([]queryResultSet, []*tfprotov6.Diagnostic, error) {
+	query, args, diags, err := d.bindQuery(ctx, config)
+	if err != nil || diags != nil {
+		return nil, diags, err
+	}
+
+	diags, err = d.validateQuery(ctx, config, query, args)
+	if err != nil || diagsHaveError(diags) {
+		return nil, diags, err
+	}
 
-	rows, err := d.db.QueryContext(ctx, query)
+	queryer, rollback, err := d.readQueryer(ctx)
 	if err != nil {
+		if diags := stoppedDiagnostics(err); diags != nil {
+			return nil, diags, nil
+		}
+		return nil, nil, err
+	}
+	defer rollback()
+
+	opts := resultOptions{
+		DecodeJSON:       d.p.decodeJSON,
+		DuplicateColumns: duplicateColumnsRename,
+	}
+	if d.p.Driver == driverSQLite {
+		// the types are inferred before running the query, so they do not depend on the rows
+		opts.ExpressionTypes = sqliteResultTypes(ctx, queryer, query, args)
+	}
+
+	rows, err := queryer.QueryContext(ctx, query, args...)
+	if err != nil {
+		if diags := stoppedDiagnostics(err); diags != nil {
+			return nil, diags, nil
+		}
 		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var rowType tftypes.Type
-	rowSet := []tftypes.Value{}
-	for rows.Next() {
-		row, ty, err := d.p.ValuesForRow(rows)
+	if v := config["duplicate_columns"]; !v.IsNull() {
+		err = v.As(&opts.DuplicateColumns)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if v := config["decode_json"]; !v.IsNull() {
+		err = v.As(&opts.DecodeJSON)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if v := config["column_types"]; !v.IsNull() {
+		var types map[string]tftypes.Value
+		err = v.As(&types)
 		if err != nil {
+			return nil, nil, err
+		}
+		opts.ColumnTypes = make(map[string]string, len(types))
+		for name, tv := range types {
+			var ty string
+			err = tv.As(&ty)
+			if err != nil {
+				return nil, nil, err
+			}
+			opts.ColumnTypes[name] = ty
+		}
+	}
+
+	var sets []queryResultSet
+	for {
+		set, setDiags, err := d.readResultSet(rows.Rows, opts, limits)
+		if err != nil {
+			err = stopError(d.p.stopCtx, query, err)
+			if diags := stoppedDiagnostics(err); diags != nil {
+				return nil, diags, nil
+			}
+			return nil, nil, err
+		}
+		if setDiags != nil {
+			return nil, setDiags, nil
+		}
+		sets = append(sets, set)
+
+		if limits.exceeded != "" {
+			diags = append(diags, limits.diagnostic(len(sets)))
+			if !limits.truncate {
+				return nil, diags, nil
+			}
+			if limits.exceeded == "max_result_bytes" {
+				// the remaining result sets are not read
+				break
+			}
+			limits.exceeded = ""
+		}
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		err = stopError(d.p.stopCtx, query, err)
+		if diags := stoppedDiagnostics(err); diags != nil {
+			return nil, diags, nil
+		}
+		return nil, nil, err
+	}
+
+	for name := range opts.ColumnTypes {
+		found := false
+		for _, set := range sets {
+			for _, col := range set.columns {
+				if col.name == name {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
 			return nil, []*tfprotov6.Diagnostic{
+				{
+					Severity: tfprotov6.DiagnosticSeverityError,
+					Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+						tftypes.AttributeName("column_types"),
+						tftypes.ElementKeyString(name),
+					}),
+					Summary: fmt.Sprintf("Column %q is not in the result of the query", name),
+				},
+			}, nil
+		}
+	}
+
+	return sets, diags, nil
+}
+
+const (
+	// onLimitError fails the query when a limit is exceeded.
+	onLimitError = "error"
+	// onLimitTruncate returns the rows up to the limit with a warning.
+	onLimitTruncate = "truncate"
+)
+
+// resultLimits bounds the size of the result of a query. maxRows applies to each result set,
+// maxBytes to all of them.
+type resultLimits struct {
+	maxRows  int64
+	maxBytes int64
+	truncate bool
+	// onLimit is set if the data source has `on_limit` to truncate the result instead
+	onLimit bool
+	// stopAfter stops reading each result set after the number of rows, without exceeding a
+	// limit, it is used when only the number of rows up to it matters
+	stopAfter int64
+
+	bytes     int64
+	truncated bool
+	// exceeded is the name of the limit exceeded while reading the current result set
+	exceeded string
+}
+
+// diagnostic returns the error, or warning if truncating, for the exceeded limit.
+func (l *resultLimits) diagnostic(resultSet int) *tfprotov6.Diagnostic {
+	diag := &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+	}
+	if l.truncate {
+		diag.Severity = tfprotov6.DiagnosticSeverityWarning
+	}
+
+	switch l.exceeded {
+	case "max_rows":
+		diag.Attribute = tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+			tftypes.AttributeName("max_rows"),
+		})
+		diag.Summary = fmt.Sprintf("Query returned more than %d rows", l.maxRows)
+		diag.Detail = fmt.Sprintf("Result set %d of the query exceeded `max_rows`.", resultSet)
+	default:
+		diag.Attribute = tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+			tftypes.AttributeName("query"),
+		})
+		diag.Summary = fmt.Sprintf("Query result is larger than %d bytes", l.maxBytes)
+		diag.Detail = "The result of the query exceeded the provider's `max_result_bytes`."
+	}
+
+	if l.truncate {
+		diag.Detail += " Only the rows up to the limit are returned."
+	} else if l.onLimit {
+		diag.Detail += " Filter the query, raise the limit, or set `on_limit` to `truncate` to return the rows up " +
+			"to the limit."
+	} else {
+		diag.Detail += " Filter the query or raise the limit."
+	}
+	return diag
+}
+
+// resultMapForKey returns the rows of the result set as a map keyed by the value of the key
+// column.
+func resultMapForKey(set queryResultSet, keyColumn string) (tftypes.Value, []*tfprotov6.Diagnostic, error) {
+	keyDiag := func(summary string) []*tfprotov6.Diagnostic {
+		return []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("key_column"),
+				}),
+				Summary: summary,
+			},
+		}
+	}
+
+	rowType := objectTypeForColumns(set.columns)
+	if _, ok := rowType.AttributeTypes[keyColumn]; !ok {
+		return tftypes.Value{}, keyDiag(fmt.Sprintf("Column %q is not in the result of the query", keyColumn)), nil
+	}
+
+	var rows []tftypes.Value
+	err := set.result.As(&rows)
+	if err != nil {
+		return tftypes.Value{}, nil, err
+	}
+
+	values := make(map[string]tftypes.Value, len(rows))
+	for i, row := range rows {
+		var attrs map[string]tftypes.Value
+		err = row.As(&attrs)
+		if err != nil {
+			return tftypes.Value{}, nil, err
+		}
+
+		key, err := keyString(attrs[keyColumn])
+		if err != nil {
+			return tftypes.Value{}, keyDiag(fmt.Sprintf("Unable to use the value of %q in row %d as a key: %s", keyColumn, i+1, err)), nil
+		}
+		if _, ok := values[key]; ok {
+			return tftypes.Value{}, keyDiag(fmt.Sprintf("Duplicate value %q for key column %q in row %d", key, keyColumn, i+1)), nil
+		}
+		values[key] = row
+	}
+
+	return tftypes.NewValue(tftypes.Map{ElementType: rowType}, values), nil, nil
+}
+
+// keyString converts a primitive value to a map key.
+func keyString(v tftypes.Value) (string, error) {
+	if v.IsNull() {
+		return "", fmt.Errorf("the value is null")
+	}
+
+	ty := v.Type()
+	switch {
+	case ty.Is(tftypes.String):
+		var s string
+		err := v.As(&s)
+		return s, err
+	case ty.Is(tftypes.Number):
+		n := &big.Float{}
+		err := v.As(&n)
+		if err != nil {
+			return "", err
+		}
+		return n.Text('f', -1), nil
+	case ty.Is(tftypes.Bool):
+		var b bool
+		err := v.As(&b)
+		return strconv.FormatBool(b), err
+	}
+
+	return "", fmt.Errorf("%s values cannot be used as keys", ty)
+}
+
+// queryResultSet is a result set of a query converted to a list of objects.
+type queryResultSet struct {
+	columns []resultColumn
+	result  tftypes.Value
+}
+
+// readResultSet reads the rows of the current result set. Errors from the rows are returned
+// as is to be checked for cancellation by the caller.
+func (d *dataQuery) readResultSet(rows *sql.Rows, opts resultOptions, limits *resultLimits) (queryResultSet, []*tfprotov6.Diagnostic, error) {
+	// the type is determined from the columns, not the values, so it is stable even if no
+	// rows are returned
+	columns, err := d.p.columnsForRows(rows, opts)
+	if dupeErr := (*duplicateColumnNamesError)(nil); errors.As(err, &dupeErr) {
+		return queryResultSet{}, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("query"),
+				}),
+				Summary: "Duplicate column names in query result",
+				Detail: fmt.Sprintf("Conflicting columns: %s. Use column aliases to give the columns unique names, "+
+					"or set `duplicate_columns` to `rename`.", dupeErr.conflicts()),
+			},
+		}, nil
+	}
+	if err != nil {
+		return queryResultSet{}, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("result"),
+				}),
+				Summary: fmt.Sprintf("unable to determine result type: %s", err),
+			},
+		}, nil
+	}
+
+	rowValues := []map[string]tftypes.Value{}
+	for rows.Next() {
+		if limits.stopAfter > 0 && int64(len(rowValues)) >= limits.stopAfter {
+			break
+		}
+		if limits.maxRows > 0 && int64(len(rowValues)) >= limits.maxRows {
+			limits.exceeded = "max_rows"
+			limits.truncated = true
+			break
+		}
+
+		row, err := d.p.ValuesForRow(rows, columns)
+		if err != nil {
+			return queryResultSet{}, []*tfprotov6.Diagnostic{
 				{
 					Severity: tfprotov6.DiagnosticSeverityError,
 					Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
@@ -115,27 +952,45 @@ func (d *dataQuery) Read(ctx context.Context, config map[string]tftypes.Value) (
 			}, nil
 		}
 
-		if rowType == nil {
-			rowType = tftypes.Object{
-				AttributeTypes: ty,
-			}
+		size := rowSize(row)
+		if limits.maxBytes > 0 && limits.bytes+size > limits.maxBytes {
+			limits.exceeded = "max_result_bytes"
+			limits.truncated = true
+			break
 		}
+		limits.bytes += size
+
+		rowValues = append(rowValues, row)
+	}
+	if err := rows.Err(); err != nil {
+		return queryResultSet{}, nil, err
+	}
 
+	err = unifyDecodedColumns(columns, rowValues)
+	if err != nil {
+		return queryResultSet{}, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+					tftypes.AttributeName("result"),
+				}),
+				Summary: err.Error(),
+			},
+		}, nil
+	}
+
+	rowType := objectTypeForColumns(columns)
+	rowSet := []tftypes.Value{}
+	for _, row := range rowValues {
 		rowSet = append(rowSet, tftypes.NewValue(
 			rowType,
 			row,
 		))
 	}
-	if rowType == nil {
-		// empty object here
-		rowType = tftypes.Object{}
-	}
 
-	return map[string]tftypes.Value{
-		"id":    config["query"],
-		"query": config["query"],
-		// "parameters": config["parameters"],
-		"result": tftypes.NewValue(
+	return queryResultSet{
+		columns: columns,
+		result: tftypes.NewValue(
 			tftypes.List{
 				ElementType: rowType,
 			},
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/paultyng/terraform-provider-sql/internal/server"
+)
+
+type dataQueryRow struct {
+	*dataQuery
+}
+
+var _ server.DataSource = (*dataQueryRow)(nil)
+
+func newDataQueryRow(db dbQueryer, p *provider) (*dataQueryRow, error) {
+	d, err := newDataQuery(db, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataQueryRow{
+		dataQuery: d,
+	}, nil
+}
+
+func (d *dataQueryRow) Schema(context.Context) *tfprotov6.Schema {
+	return &tfprotov6.Schema{
+		Block: &tfprotov6.SchemaBlock{
+			Description: "The `sql_query_row` datasource allows you to execute a SQL query that returns a single row, " +
+				"for example to look up a record by its key. It is an error if the query returns no rows (unless " +
+				"`allow_empty` is set) or more than one row.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Attributes: append(queryAttributes("result"), []*tfprotov6.SchemaAttribute{
+				{
+					Name:     "allow_empty",
+					Optional: true,
+					Description: "Allow the query to return no rows, `result` is then null. Default is `false`, which " +
+						"is an error if the query returns no rows.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.Bool,
+				},
+
+				{
+					Name:     "result",
+					Computed: true,
+					Description: "The row returned by the query. This will be an object with attributes with names that " +
+						"match column names and types that match column types, the same as an element of `result` " +
+						"of `sql_query`.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.DynamicPseudoType,
+				},
+
+				deprecatedIDAttribute(),
+			}...),
+		},
+	}
+}
+
+func (d *dataQueryRow) Validate(ctx context.Context, config map[string]tftypes.Value) ([]*tfprotov6.Diagnostic, error) {
+	return validateQueryAttributes(config)
+}
+
+func (d *dataQueryRow) Read(ctx context.Context, config map[string]tftypes.Value) (map[string]tftypes.Value, []*tfprotov6.Diagnostic, error) {
+	allowEmpty := false
+	if v := config["allow_empty"]; !v.IsNull() {
+		err := v.As(&allowEmpty)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	row, diags, err := d.readSingleRow(ctx, config, allowEmpty)
+	if err != nil || diagsHaveError(diags) {
+		return nil, diags, err
+	}
+
+	return map[string]tftypes.Value{
+		"id":                queryID(config),
+		"query":             config["query"],
+		"query_file":        config["query_file"],
+		"vars":              config["vars"],
+		"validate_on_plan":  config["validate_on_plan"],
+		"parameters":        config["parameters"],
+		"decode_json":       config["decode_json"],
+		"column_types":      config["column_types"],
+		"duplicate_columns": config["duplicate_columns"],
+		"allow_empty":       config["allow_empty"],
+		"result":            row,
+	}, diags, nil
+}
+
+// readSingleRow runs the query and returns the only row of the first result set, if the
+// query returns no rows and allowEmpty is set the row is null.
+func (d *dataQuery) readSingleRow(ctx context.Context, config map[string]tftypes.Value, allowEmpty bool) (tftypes.Value, []*tfprotov6.Diagnostic, error) {
+	sets, diags, err := d.run(ctx, config, &resultLimits{
+		maxBytes: d.p.maxResultBytes,
+		// a second row is enough to know the query returned more than one
+		stopAfter: 2,
+	})
+	if err != nil || sets == nil {
+		return tftypes.Value{}, diags, err
+	}
+
+	var rows []tftypes.Value
+	err = sets[0].result.As(&rows)
+	if err != nil {
+		return tftypes.Value{}, nil, err
+	}
+
+	switch {
+	case len(rows) == 1:
+		return rows[0], diags, nil
+	case len(rows) == 0 && allowEmpty:
+		return tftypes.NewValue(objectTypeForColumns(sets[0].columns), nil), diags, nil
+	}
+
+	summary := "Query returned no rows"
+	detail := "The query must return exactly one row, set `allow_empty` if no rows is expected."
+	if len(rows) > 1 {
+		summary = "Query returned more than one row"
+		detail = "The query must return exactly one row, use `sql_query` for queries that return more than one row."
+	}
+	return tftypes.Value{}, append(diags, &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+			tftypes.AttributeName("query"),
+		}),
+		Summary: summary,
+		Detail:  detail,
+	}), nil
+}
+
+// diagsHaveError returns true if any of the diagnostics is an error.
+func diagsHaveError(diags []*tfprotov6.Diagnostic) bool {
+	for _, diag := range diags {
+		if diag != nil && diag.Severity == tfprotov6.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	helperresource "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataQueryRow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query_row" "test" {
+	query = "select 1 as id where 1 = 0"
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Query returned no rows`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query_row" "test" {
+	query = "select 1 as id union all select 2 union all select 3"
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Query returned more than one row`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query_row" "test" {
+	query = "select 1 as id, 'foo' as name"
+}
+
+data "sql_query_row" "empty" {
+	query       = "select 1 as id where 1 = 0"
+	allow_empty = true
+}
+
+output "name" {
+	value = data.sql_query_row.test.result.name
+}
+
+output "id" {
+	value = data.sql_query_row.test.result.id + 1
+}
+
+output "empty" {
+	value = data.sql_query_row.empty.result == null
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("name", "foo"),
+							helperresource.TestCheckOutput("id", "2"),
+							helperresource.TestCheckOutput("empty", "true"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
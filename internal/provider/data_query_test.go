@@ -1,10 +1,17 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
 	helperresource "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -64,8 +71,12 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					"time null":     {"cast(null as time)", ""},
 					"unsigned":      {"cast(1 as unsigned)", "1"},
 					"unsigned null": {"cast(null as unsigned)", ""},
+					"unsigned max":  {"nullif(cast(18446744073709551615 as unsigned), 0)", "18446744073709551615"},
 					"year":          {"cast(2020 as year)", "2020"},
 					"year null":     {"cast(null as year)", ""},
+					"binary":        {"cast('foo' as binary)", "Zm9v"},
+					"binary null":   {"cast(null as binary)", ""},
+					"bit":           {"b'101'", "5"},
 				}
 			case "postgres":
 				literals = map[string]struct {
@@ -86,6 +97,7 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					"macaddr":                  {"cast('08:00:2b:01:02:03' as macaddr)", "08:00:2b:01:02:03"},
 					"macaddr8":                 {"cast('08:00:2b:01:02:03:04:05' as macaddr8)", "08:00:2b:01:02:03:04:05"},
 					"numeric":                  {"cast(1.234 as numeric)", "1.234"},
+					"numeric precision":        {"cast(12345678901234567890.123456789 as numeric)", "12345678901234567890.123456789"},
 					"real":                     {"cast(.125 as real)", "0.125"},
 					"smallint":                 {"cast(12 as smallint)", "12"},
 					"text":                     {"cast('foo' as text)", "foo"},
@@ -96,9 +108,27 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					"uuid":                     {"cast('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11' as uuid)", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
 					"xml":                      {`XMLPARSE (DOCUMENT '<?xml version="1.0"?><book><title>Manual</title><chapter>...</chapter></book>')`, ""},
 
-					// TODO: money is not supported properly, just as string
+					// money is a string unless numeric_mode is "number"
 					"money": {"cast('12.34' as money)", ""},
 
+					"bytea":         {`cast('\xDEADBEEF' as bytea)`, "3q2+7w=="},
+					"bytea null":    {"cast(null as bytea)", ""},
+					"interval":      {"cast('1 year 2 months 3 days 04:05:06.5' as interval)", "P1Y2M3DT4H5M6.5S"},
+					"interval null": {"cast(null as interval)", ""},
+					"uuid null":     {"cast(null as uuid)", ""},
+
+					// arrays are lists, values are not checked
+					"integer array":           {"cast('{1,2,3}' as integer[])", ""},
+					"text array":              {"cast('{foo,NULL,\"bar baz\"}' as text[])", ""},
+					"multi-dimensional array": {"cast('{{1,2},{3,4}}' as integer[][])", ""},
+					"empty array":             {"cast('{}' as uuid[])", ""},
+					"non-finite array":        {"cast('{1,NaN,Infinity}' as float8[])", ""},
+					"interval array":          {"cast('{\"1 year 2 months\",NULL}' as interval[])", ""},
+					"bytea array":             {`cast('{"\\xDEADBEEF"}' as bytea[])`, ""},
+
+					// NaN and infinity are null
+					"double precision nan": {"cast('NaN' as double precision)", ""},
+
 					// TODO: actually convert this to HCL types?
 					"json":  {"cast('[1, 2]' as json)", ""},
 					"jsonb": {"cast('[4, 5, null]' as jsonb)", ""},
@@ -106,10 +136,7 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					// TODO: other data types:
 
 					// box	 	rectangular box on a plane
-					// bytea	 	binary data (“byte array”)
-					// TODO: "bytea": `cast('\xDEADBEEF' as bytea)`,
 					// circle	 	circle on a plane
-					// interval [ fields ] [ (p) ]	 	time span
 					// line	 	infinite line on a plane
 					// lseg	 	line segment on a plane
 					// path	 	geometric path on a plane
@@ -128,6 +155,7 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					delete(literals, "macaddr")
 					delete(literals, "macaddr8")
 					delete(literals, "money")
+					delete(literals, "multi-dimensional array")
 					delete(literals, "time with time zone")
 					delete(literals, "timestamp with time zone")
 					delete(literals, "xml")
@@ -145,7 +173,7 @@ func TestDataQuery_driverTypes(t *testing.T) {
 
 					"bit": {"cast(1 as bit)", "true"},
 
-					// TODO: these are not yet supported properly, just as string
+					// these are strings unless numeric_mode is "number"
 					"decimal":    {"cast(123.4 as decimal(9,3))", ""},
 					"money":      {"cast(123.45 as money)", ""},
 					"smallmoney": {"cast(-123.45 as smallmoney)", ""},
@@ -165,13 +193,15 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					"ntext":    {"cast('abcdef' as ntext)", "abcdef"},
 
 					// other data types
-					"uniqueidentifier": {"cast('0E984725-C51C-4BF4-9960-E1C80E27ABA0' as uniqueidentifier)", "0E984725-C51C-4BF4-9960-E1C80E27ABA0"},
+					"uniqueidentifier":      {"cast('0E984725-C51C-4BF4-9960-E1C80E27ABA0' as uniqueidentifier)", "0E984725-C51C-4BF4-9960-E1C80E27ABA0"},
+					"uniqueidentifier null": {"cast(null as uniqueidentifier)", ""},
+
+					// binary strings
+					"binary":    {"cast('abc' as binary(3))", "YWJj"},
+					"varbinary": {"cast('abc' as varbinary(10))", "YWJj"},
 
 					// TODO: other data types:
 
-					// binary
-					// varbinary
-					// image
 					// cursor
 					// rowversion
 					// hierarchyid
@@ -180,6 +210,29 @@ func TestDataQuery_driverTypes(t *testing.T) {
 					// spatial types
 					// table?
 				}
+			case "sqlite":
+				literals = map[string]struct {
+					sql      string
+					expected string
+				}{
+					// https://www.sqlite.org/datatype3.html
+
+					"integer":      {"cast(12345 as integer)", "12345"},
+					"integer null": {"cast(null as integer)", ""},
+					"real":         {"cast(.125 as real)", "0.125"},
+					"real null":    {"cast(null as real)", ""},
+					"numeric":      {"cast('1.5' as numeric)", "1.5"},
+					"text":         {"cast(123 as text)", "123"},
+					"text null":    {"cast(null as text)", ""},
+
+					// no declared type, typed from the query
+					"int literal":   {"7", "7"},
+					"float literal": {"1.2", "1.2"},
+					"text literal":  {"'foo'", "foo"},
+					"null literal":  {"null", ""},
+
+					"blob": {"x'DEADBEEF'", "3q2+7w=="},
+				}
 			}
 
 			if len(literals) == 0 {
@@ -236,3 +289,1120 @@ output "query" {
 		})
 	}
 }
+
+func TestDataQuery_parameters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "positional" {
+	query      = "select $2 as name, $1 as value"
+	parameters = [42, "it's a string"]
+}
+
+data "sql_query" "named" {
+	query      = "select :name as name -- :ignored"
+	parameters = {
+		name = "it's a string"
+	}
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckResourceAttr("data.sql_query.positional", "result.0.name", "it's a string"),
+							helperresource.TestCheckResourceAttr("data.sql_query.positional", "result.0.value", "42"),
+							helperresource.TestCheckResourceAttr("data.sql_query.named", "result.0.name", "it's a string"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_emptyResultType(t *testing.T) {
+	p := &provider{
+		Driver: driverSQLite,
+		url:    "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "tftest.db")),
+	}
+	p.DB = &lazyDB{
+		open: p.open,
+	}
+	defer p.DB.Close()
+
+	ctx := context.Background()
+	_, err := p.DB.ExecContext(ctx, "create table test (id integer, name text)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := newDataQuery(p.DB, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := tftypes.List{
+		ElementType: tftypes.Object{
+			AttributeTypes: map[string]tftypes.Type{
+				"id":   tftypes.Number,
+				"name": tftypes.String,
+			},
+		},
+	}
+
+	// the type should be the same with and without rows
+	for i := 0; i < 2; i++ {
+		state, diags, err := d.Read(ctx, map[string]tftypes.Value{
+			"query":      tftypes.NewValue(tftypes.String, "select id, name from test"),
+			"parameters": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diags) > 0 {
+			t.Fatalf("unexpected diagnostics: %s", diags[0].Summary)
+		}
+
+		if actual := state["result"].Type(); !actual.Equal(expected) {
+			t.Fatalf("expected type %s, got %s", expected, actual)
+		}
+
+		_, err = p.DB.ExecContext(ctx, "insert into test (id, name) values (1, 'foo')")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDataQuery_sqliteExpressionTypes(t *testing.T) {
+	p := &provider{
+		Driver: driverSQLite,
+		url:    "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "tftest.db")),
+	}
+	p.DB = &lazyDB{
+		open: p.open,
+	}
+	defer p.DB.Close()
+
+	ctx := context.Background()
+	_, err := p.DB.ExecContext(ctx, "create table test (id integer, name text)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := newDataQuery(p.DB, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := tftypes.List{
+		ElementType: tftypes.Object{
+			AttributeTypes: map[string]tftypes.Type{
+				"n": tftypes.Number,
+				"c": tftypes.Number,
+				"f": tftypes.Number,
+				"m": tftypes.String,
+				"s": tftypes.String,
+			},
+		},
+	}
+
+	// the type should be the same with and without rows
+	for i := 0; i < 2; i++ {
+		state, diags, err := d.Read(ctx, map[string]tftypes.Value{
+			"query": tftypes.NewValue(tftypes.String, "select id + 1 as n, count(*) as c, id * 1.5 as f, "+
+				"max(id) as m, 'a' || name as s from test group by id"),
+			"parameters": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diags) > 0 {
+			t.Fatalf("unexpected diagnostics: %s", diags[0].Summary)
+		}
+
+		if actual := state["result"].Type(); !actual.Equal(expected) {
+			t.Fatalf("expected type %s, got %s", expected, actual)
+		}
+
+		_, err = p.DB.ExecContext(ctx, "insert into test (id, name) values (1, 'foo')")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDataQuery_sqliteDeclaredTypes(t *testing.T) {
+	p := &provider{
+		Driver: driverSQLite,
+		url:    "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "tftest.db")),
+	}
+	p.DB = &lazyDB{
+		open: p.open,
+	}
+	defer p.DB.Close()
+
+	ctx := context.Background()
+	_, err := p.DB.ExecContext(ctx, `create table test (id uuid, name string, price decimal(10,2), ratio real)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.DB.ExecContext(ctx, `insert into test values ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'foo', 12.5, 0.125)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := newDataQuery(p.DB, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, diags, err := d.Read(ctx, map[string]tftypes.Value{
+		"query":      tftypes.NewValue(tftypes.String, "select * from test"),
+		"parameters": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags[0].Summary)
+	}
+
+	expected := tftypes.NewValue(tftypes.List{
+		ElementType: tftypes.Object{
+			AttributeTypes: map[string]tftypes.Type{
+				"id":    tftypes.String,
+				"name":  tftypes.String,
+				"price": tftypes.Number,
+				"ratio": tftypes.Number,
+			},
+		},
+	}, []tftypes.Value{
+		tftypes.NewValue(tftypes.Object{
+			AttributeTypes: map[string]tftypes.Type{
+				"id":    tftypes.String,
+				"name":  tftypes.String,
+				"price": tftypes.Number,
+				"ratio": tftypes.Number,
+			},
+		}, map[string]tftypes.Value{
+			"id":    tftypes.NewValue(tftypes.String, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"),
+			"name":  tftypes.NewValue(tftypes.String, "foo"),
+			"price": tftypes.NewValue(tftypes.Number, big.NewFloat(12.5)),
+			"ratio": tftypes.NewValue(tftypes.Number, big.NewFloat(0.125)),
+		}),
+	})
+	if actual := state["result"]; !actual.Equal(expected) {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestDataQuery_decodeJSON(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			if server.ServerType == "sqlserver" {
+				t.Skip("sqlserver does not have a JSON type")
+			}
+
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+	decode_json    = true
+}
+
+resource "sql_migrate" "db" {
+	migration {
+		id   = "create table"
+		up   = "CREATE TABLE decode_json_test (id integer, doc json);"
+		down = "DROP TABLE decode_json_test;"
+	}
+
+	migration {
+		id   = "insert rows"
+		up   = "INSERT INTO decode_json_test VALUES (1, '{\"name\": \"foo\", \"tags\": [\"a\", \"b\"]}'), (2, '{\"enabled\": true, \"tags\": []}'), (3, null);"
+		down = "DELETE FROM decode_json_test;"
+	}
+}
+
+data "sql_query" "decoded" {
+	depends_on = [sql_migrate.db]
+
+	query = "select id, doc from decode_json_test order by id"
+}
+
+data "sql_query" "raw" {
+	depends_on = [sql_migrate.db]
+
+	query       = "select doc from decode_json_test where id = 2"
+	decode_json = false
+}
+
+output "tags" {
+	value = join(",", data.sql_query.decoded.result[0].doc.tags)
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("tags", "a,b"),
+							helperresource.TestCheckResourceAttr("data.sql_query.decoded", "result.0.doc.name", "foo"),
+							helperresource.TestCheckNoResourceAttr("data.sql_query.decoded", "result.0.doc.enabled"),
+							helperresource.TestCheckResourceAttr("data.sql_query.decoded", "result.1.doc.enabled", "true"),
+							helperresource.TestCheckResourceAttr("data.sql_query.decoded", "result.1.doc.tags.#", "0"),
+							helperresource.TestCheckNoResourceAttr("data.sql_query.decoded", "result.2.doc"),
+							helperresource.TestCheckResourceAttrSet("data.sql_query.raw", "result.0.doc"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_numericMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, scheme, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var query string
+			switch scheme {
+			case "mysql":
+				query = "select cast('12345678901234567890.125' as decimal(30,3)) as amount"
+			case "postgres":
+				query = "select cast('12345678901234567890.125' as numeric(30,3)) as amount"
+			case "sqlserver":
+				query = "select cast(12345678901234567890.125 as decimal(30,3)) as amount"
+			default:
+				t.Skipf("no exact numeric types to test")
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+	numeric_mode   = "number"
+}
+
+data "sql_query" "test" {
+	query = %q
+}
+
+output "amount" {
+	value = data.sql_query.test.result[0].amount + 1
+}
+				`, url, query),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("amount", "12345678901234567891.125"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_columnTypes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = "select 1 as s"
+
+	column_types = {
+		missing = "string"
+	}
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Column "missing" is not in the result of the query`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = "select 1 as s"
+
+	column_types = {
+		s = "date"
+	}
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Unsupported column type "date"`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = "select 1 as s, '2' as n, 1 as b, '{\"x\": [1]}' as j, 'foo' as bin"
+
+	column_types = {
+		s   = "string"
+		n   = "number"
+		b   = "bool"
+		j   = "json"
+		bin = "base64"
+	}
+}
+
+output "s" {
+	value = data.sql_query.test.result[0].s
+}
+
+output "n" {
+	value = data.sql_query.test.result[0].n + 1
+}
+
+output "b" {
+	value = data.sql_query.test.result[0].b
+}
+
+output "j" {
+	value = data.sql_query.test.result[0].j.x[0]
+}
+
+output "bin" {
+	value = data.sql_query.test.result[0].bin
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("s", "1"),
+							helperresource.TestCheckOutput("n", "3"),
+							helperresource.TestCheckOutput("b", "true"),
+							helperresource.TestCheckOutput("j", "1"),
+							helperresource.TestCheckOutput("bin", "Zm9v"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_duplicateColumns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query             = "select 1 as id, 2 as id"
+	duplicate_columns = "error"
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Conflicting columns: "id" at positions 1, 2`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = "select 1 as id, 2 as id"
+}
+
+output "id" {
+	value = data.sql_query.test.result[0].id
+}
+
+output "id_2" {
+	value = data.sql_query.test.result[0].id_2
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("id", "1"),
+							helperresource.TestCheckOutput("id_2", "2"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_columns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+resource "sql_migrate" "db" {
+	migration {
+		id   = "create table"
+		up   = "CREATE TABLE columns_test (name varchar(10), id integer);"
+		down = "DROP TABLE columns_test;"
+	}
+}
+
+data "sql_query" "test" {
+	depends_on = [sql_migrate.db]
+
+	query = "select name, id from columns_test"
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckResourceAttr("data.sql_query.test", "columns.#", "2"),
+							helperresource.TestCheckResourceAttr("data.sql_query.test", "columns.0.position", "1"),
+							helperresource.TestCheckResourceAttr("data.sql_query.test", "columns.0.name", "name"),
+							helperresource.TestCheckResourceAttrSet("data.sql_query.test", "columns.0.database_type"),
+							helperresource.TestCheckResourceAttr("data.sql_query.test", "columns.1.position", "2"),
+							helperresource.TestCheckResourceAttr("data.sql_query.test", "columns.1.name", "id"),
+							helperresource.TestCheckResourceAttrSet("data.sql_query.test", "columns.1.database_type"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_results(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, scheme, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// only sqlserver returns multiple result sets without driver specific options
+			query := "select 1 as a"
+			expectedB := ""
+			if scheme == "sqlserver" {
+				query = "select 1 as a; select 'x' as b"
+				expectedB = "x"
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = %q
+}
+
+output "a" {
+	value = data.sql_query.test.results[0][0].a
+}
+
+output "b" {
+	value = length(data.sql_query.test.results) > 1 ? data.sql_query.test.results[1][0].b : ""
+}
+
+output "result" {
+	value = data.sql_query.test.result[0].a
+}
+				`, url, query),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("a", "1"),
+							helperresource.TestCheckOutput("b", expectedB),
+							helperresource.TestCheckOutput("result", "1"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_limits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	const query = "select 1 as i union all select 2 union all select 3"
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query    = %q
+	max_rows = 2
+}
+				`, url, query),
+						ExpectError: regexp.MustCompile(`Query returned more than 2 rows`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns   = 0
+	max_result_bytes = 10
+}
+
+data "sql_query" "test" {
+	query = %q
+}
+				`, url, query),
+						ExpectError: regexp.MustCompile(`Query result is larger than 10 bytes`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "rows" {
+	query    = %[2]q
+	max_rows = 2
+	on_limit = "truncate"
+}
+
+data "sql_query" "all" {
+	query    = %[2]q
+	max_rows = 3
+	on_limit = "truncate"
+}
+				`, url, query),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckResourceAttr("data.sql_query.rows", "result.#", "2"),
+							helperresource.TestCheckResourceAttr("data.sql_query.rows", "truncated", "true"),
+							helperresource.TestCheckResourceAttr("data.sql_query.all", "result.#", "3"),
+							helperresource.TestCheckResourceAttr("data.sql_query.all", "truncated", "false"),
+						),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns   = 0
+	max_result_bytes = 10
+}
+
+data "sql_query" "bytes" {
+	query    = %q
+	on_limit = "truncate"
+}
+				`, url, query),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckResourceAttr("data.sql_query.bytes", "result.#", "1"),
+							helperresource.TestCheckResourceAttr("data.sql_query.bytes", "truncated", "true"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_keyColumn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query      = "select 1 as id union all select 1"
+	key_column = "id"
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Duplicate value "1" for key column "id" in row 2`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query      = "select 1 as id"
+	key_column = "name"
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Column "name" is not in the result of the query`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query      = "select 1 as id, 'foo' as name union all select 2, 'bar'"
+	key_column = "id"
+}
+
+output "name" {
+	value = data.sql_query.test.result_map["2"].name
+}
+
+output "keys" {
+	value = join(",", keys(data.sql_query.test.result_map))
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("name", "bar"),
+							helperresource.TestCheckOutput("keys", "1,2"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_queryFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	queryFile := filepath.Join(t.TempDir(), "query.sql")
+	err := os.WriteFile(queryFile, []byte("select ${name} as ${column:identifier}, 2 as n where 2 in (${ids})"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query      = "select 1"
+	query_file = %q
+}
+				`, url, queryFile),
+						ExpectError: regexp.MustCompile("Exactly one of `query` or `query_file` is required"),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query_file = %q
+	vars = {
+		name = "it's"
+	}
+}
+				`, url, queryFile),
+						ExpectError: regexp.MustCompile(`variable "column" is not set in vars`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query_file = %q
+	vars = {
+		name   = "it's"
+		column = "my name"
+		ids    = [1, 2]
+	}
+}
+
+output "name" {
+	value = data.sql_query.test.result[0]["my name"]
+}
+
+output "id" {
+	value = data.sql_query.test.id
+}
+				`, url, queryFile),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("name", "it's"),
+							helperresource.TestCheckOutput("id", queryFile),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_validateOnPlan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query            = "select id from missing_table"
+	validate_on_plan = true
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Invalid query`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query            = "select 1 as n where 1 = :one"
+	parameters       = { one = 1 }
+	validate_on_plan = true
+}
+
+output "n" {
+	value = data.sql_query.test.result[0].n
+}
+				`, url),
+						Check: helperresource.TestCheckOutput("n", "1"),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_validateOnPlanBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			// the data source is read more than once per step
+			var createTable string
+			switch server.ServerType {
+			case "sqlite":
+				createTable = "create table if not exists validate_batch (id integer)"
+			case "sqlserver":
+				createTable = "if object_id('validate_batch') is null create table validate_batch (id int)"
+			default:
+				t.Skip("the driver does not run batches of statements")
+			}
+
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						// the batch is not validated, as the table does not exist until it runs
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns               = 0
+	allow_writes_in_data_sources = true
+}
+
+data "sql_query" "test" {
+	query            = "%s; select count(*) as n from validate_batch"
+	validate_on_plan = true
+}
+
+output "n" {
+	value = data.sql_query.test.result[0].n
+}
+				`, url, createTable),
+						Check: helperresource.TestCheckOutput("n", "0"),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataQuery_readOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the data source is read more than once per step
+			writeQuery := "create table if not exists data_source_write (id int)"
+			if server.ServerType == "sqlserver" {
+				writeQuery = "if object_id('data_source_write') is null create table data_source_write (id int)"
+			}
+
+			writeStep := helperresource.TestStep{
+				Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = %q
+}
+				`, url, writeQuery),
+			}
+
+			var steps []helperresource.TestStep
+			switch server.ServerType {
+			case "sqlite", "sqlserver":
+				// read-only transactions are not supported, the write is rolled back
+				steps = append(steps, writeStep, helperresource.TestStep{
+					Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query" "test" {
+	query = "select id from data_source_write"
+}
+				`, url),
+					ExpectError: regexp.MustCompile(`data_source_write`),
+				})
+			default:
+				writeStep.ExpectError = regexp.MustCompile(`(?i)read[- ]only transaction`)
+				steps = append(steps, writeStep)
+			}
+
+			steps = append(steps, helperresource.TestStep{
+				Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+
+	allow_writes_in_data_sources = true
+}
+
+data "sql_query" "test" {
+	query = %q
+}
+
+output "rows" {
+	value = length(data.sql_query.test.result)
+}
+				`, url, writeQuery),
+				Check: helperresource.TestCheckOutput("rows", "0"),
+			})
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps:                    steps,
+			})
+		})
+	}
+}
+
+func TestResultLimitsDiagnostic(t *testing.T) {
+	for name, c := range map[string]struct {
+		limits   resultLimits
+		expected string
+	}{
+		"on_limit": {
+			resultLimits{maxBytes: 10, onLimit: true, exceeded: "max_result_bytes"},
+			"The result of the query exceeded the provider's `max_result_bytes`. Filter the query, raise the limit, " +
+				"or set `on_limit` to `truncate` to return the rows up to the limit.",
+		},
+		"no on_limit": {
+			resultLimits{maxBytes: 10, exceeded: "max_result_bytes"},
+			"The result of the query exceeded the provider's `max_result_bytes`. Filter the query or raise the limit.",
+		},
+		"truncate": {
+			resultLimits{maxRows: 2, onLimit: true, truncate: true, exceeded: "max_rows"},
+			"Result set 1 of the query exceeded `max_rows`. Only the rows up to the limit are returned.",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			diag := c.limits.diagnostic(1)
+			if diag.Detail != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, diag.Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/paultyng/terraform-provider-sql/internal/server"
+)
+
+type dataQueryValue struct {
+	*dataQuery
+}
+
+var _ server.DataSource = (*dataQueryValue)(nil)
+
+func newDataQueryValue(db dbQueryer, p *provider) (*dataQueryValue, error) {
+	d, err := newDataQuery(db, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataQueryValue{
+		dataQuery: d,
+	}, nil
+}
+
+func (d *dataQueryValue) Schema(context.Context) *tfprotov6.Schema {
+	return &tfprotov6.Schema{
+		Block: &tfprotov6.SchemaBlock{
+			Description: "The `sql_query_value` datasource allows you to execute a SQL query that returns a single " +
+				"value (one column of one row), for example a setting or a count. It is an error if the query " +
+				"returns more than one column, or a number of rows other than one.",
+			DescriptionKind: tfprotov6.StringKindMarkdown,
+			Attributes: append(queryAttributes("result"), []*tfprotov6.SchemaAttribute{
+				{
+					Name:     "result",
+					Computed: true,
+					Description: "The value returned by the query. The type matches the type of the column, the same as " +
+						"an attribute of `result` of `sql_query`.",
+					DescriptionKind: tfprotov6.StringKindMarkdown,
+					Type:            tftypes.DynamicPseudoType,
+				},
+
+				deprecatedIDAttribute(),
+			}...),
+		},
+	}
+}
+
+func (d *dataQueryValue) Validate(ctx context.Context, config map[string]tftypes.Value) ([]*tfprotov6.Diagnostic, error) {
+	return validateQueryAttributes(config)
+}
+
+func (d *dataQueryValue) Read(ctx context.Context, config map[string]tftypes.Value) (map[string]tftypes.Value, []*tfprotov6.Diagnostic, error) {
+	row, diags, err := d.readSingleRow(ctx, config, false)
+	if err != nil || diagsHaveError(diags) {
+		return nil, diags, err
+	}
+
+	var attrs map[string]tftypes.Value
+	err = row.As(&attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(attrs) != 1 {
+		return nil, append(diags, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Attribute: tftypes.NewAttributePathWithSteps([]tftypes.AttributePathStep{
+				tftypes.AttributeName("query"),
+			}),
+			Summary: fmt.Sprintf("Query returned %d columns", len(attrs)),
+			Detail:  "The query must return exactly one column, use `sql_query_row` for queries that return more than one column.",
+		}), nil
+	}
+
+	var value tftypes.Value
+	for _, v := range attrs {
+		value = v
+	}
+
+	return map[string]tftypes.Value{
+		"id":                queryID(config),
+		"query":             config["query"],
+		"query_file":        config["query_file"],
+		"vars":              config["vars"],
+		"validate_on_plan":  config["validate_on_plan"],
+		"parameters":        config["parameters"],
+		"decode_json":       config["decode_json"],
+		"column_types":      config["column_types"],
+		"duplicate_columns": config["duplicate_columns"],
+		"result":            value,
+	}, diags, nil
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	helperresource "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataQueryValue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query_value" "test" {
+	query = "select 1 as id, 'foo' as name"
+}
+				`, url),
+						ExpectError: regexp.MustCompile(`Query returned 2 columns`),
+					},
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %q
+
+	max_idle_conns = 0
+}
+
+data "sql_query_value" "number" {
+	query = "select 41 as answer"
+}
+
+data "sql_query_value" "string" {
+	query = "select 'foo' as name"
+}
+
+output "number" {
+	value = data.sql_query_value.number.result + 1
+}
+
+output "string" {
+	value = data.sql_query_value.string.result
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("number", "42"),
+							helperresource.TestCheckOutput("string", "foo"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
@@ -4,63 +4,96 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	// database drivers
-	_ "github.com/denisenkom/go-mssqldb"
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/jackc/pgx/v4/stdlib"
-
-	// TODO: sqlite? need to use a pure go driver, i think this one is...
-	// _ "modernc.org/sqlite"
-
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 type dbQueryer interface {
-	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*queryRows, error)
+}
+
+type dbPreparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type dbBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*queryTx, error)
 }
 
 type dbExecer interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-func (p *provider) connect(dsn string) error {
-	var err error
+func (p *provider) connect(url string) (*sql.DB, error) {
+	driver, dsn, err := driverForURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := p.connector(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+
+	db := sql.OpenDB(connector)
+
+	// force this to zero, but let callers override config
+	db.SetMaxIdleConns(0)
+
+	return db, nil
+}
+
+// driverForURL determines the driver from the scheme of the URL and returns the data source
+// name in the format expected by that driver.
+func driverForURL(url string) (driverName, string, error) {
+	scheme, err := schemeFromURL(url)
+	if err != nil {
+		return "", "", err
+	}
 
-	scheme, err := schemeFromURL(dsn)
+	driver, err := driverForScheme(scheme)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
+	dsn := url
 	switch scheme {
-	case "postgres", "postgresql":
-		// TODO: use consts for these driver names?
-		p.Driver = "pgx"
 	case "mysql":
-		p.Driver = "mysql"
 		dsn = strings.TrimPrefix(dsn, "mysql://")
 		// TODO: multistatements? see go-migrate's implementation
 		// https://github.com/golang-migrate/migrate/blob/master/database/mysql/mysql.go
 
 		// TODO: also set parseTime=true https://github.com/go-sql-driver/mysql#parsetime
-	case "sqlserver":
-		p.Driver = "sqlserver"
-	default:
-		return fmt.Errorf("unexpected datasource name scheme: %q", scheme)
+	case "sqlite":
+		dsn = strings.TrimPrefix(dsn, "sqlite://")
 	}
 
-	p.DB, err = sql.Open(string(p.Driver), dsn)
-	if err != nil {
-		return fmt.Errorf("unable to open database: %w", err)
-	}
+	return driver, dsn, nil
+}
 
-	// force this to zero, but let callers override config
-	p.DB.SetMaxIdleConns(0)
+func driverForScheme(scheme string) (driverName, error) {
+	switch scheme {
+	case "postgres", "postgresql":
+		return driverPGX, nil
+	case "mysql":
+		return driverMySQL, nil
+	case "sqlserver":
+		return driverSQLServer, nil
+	case "sqlite":
+		return driverSQLite, nil
+	case "file":
+		// SQLite URI filenames are passed to the driver as is, see https://www.sqlite.org/uri.html
+		return driverSQLite, nil
+	}
 
-	return nil
+	return "", fmt.Errorf("unexpected datasource name scheme: %q", scheme)
 }
 
 func schemeFromURL(url string) (string, error) {
@@ -78,47 +111,349 @@ func schemeFromURL(url string) (string, error) {
 	return url[0:i], nil
 }
 
-func (p *provider) ValuesForRow(rows *sql.Rows) (map[string]tftypes.Value, map[string]tftypes.Type, error) {
+// resultOptions configures how query results are converted to Terraform values.
+type resultOptions struct {
+	// DecodeJSON decodes JSON columns in to Terraform values instead of strings.
+	DecodeJSON bool
+	// ColumnTypes overrides the type inferred from the driver by column name, see columnTypes.
+	ColumnTypes map[string]string
+	// DuplicateColumns is the policy for columns with the same name, either
+	// duplicateColumnsRename or duplicateColumnsError.
+	DuplicateColumns string
+	// ExpressionTypes are the types inferred from the query for SQLite columns without a
+	// declared type, by position, see sqliteResultTypes.
+	ExpressionTypes []tftypes.Type
+}
+
+const (
+	// duplicateColumnsRename suffixes duplicate column names with their occurrence, ie. `id_2`.
+	duplicateColumnsRename = "rename"
+	// duplicateColumnsError fails the query if column names are duplicated.
+	duplicateColumnsError = "error"
+)
+
+// duplicateColumnNamesError is returned when the result has duplicate column names and the
+// policy is duplicateColumnsError.
+type duplicateColumnNamesError struct {
+	// positions are the 1 based positions of the columns by duplicated name
+	positions map[string][]int
+}
+
+func (e *duplicateColumnNamesError) Error() string {
+	return fmt.Sprintf("duplicate column names in result: %s", e.conflicts())
+}
+
+// conflicts lists the duplicated names and their positions, ie. `"id" at positions 1, 3`.
+func (e *duplicateColumnNamesError) conflicts() string {
+	names := make([]string, 0, len(e.positions))
+	for name := range e.positions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dupes := make([]string, 0, len(names))
+	for _, name := range names {
+		positions := make([]string, 0, len(e.positions[name]))
+		for _, pos := range e.positions[name] {
+			positions = append(positions, strconv.Itoa(pos))
+		}
+		dupes = append(dupes, fmt.Sprintf("%q at positions %s", name, strings.Join(positions, ", ")))
+	}
+	return strings.Join(dupes, "; ")
+}
+
+// columnNames returns unique names for the columns of a result. Anonymous columns are named
+// by their position (ie. `column0`), duplicates are renamed or rejected depending on the policy.
+func columnNames(names []string, policy string) ([]string, error) {
+	unique := make([]string, len(names))
+	positions := map[string][]int{}
+	for i, name := range names {
+		if name == "" || name == "?column?" {
+			name = fmt.Sprintf("column%d", i)
+		}
+		unique[i] = name
+		positions[name] = append(positions[name], i+1)
+	}
+
+	for name, pos := range positions {
+		if len(pos) < 2 {
+			delete(positions, name)
+		}
+	}
+	if len(positions) == 0 {
+		return unique, nil
+	}
+	if policy == duplicateColumnsError {
+		return nil, &duplicateColumnNamesError{positions: positions}
+	}
+
+	used := map[string]bool{}
+	for _, name := range unique {
+		used[name] = true
+	}
+	seen := map[string]bool{}
+	next := map[string]int{}
+	for i, name := range unique {
+		if !seen[name] {
+			seen[name] = true
+			continue
+		}
+		if next[name] == 0 {
+			next[name] = 2
+		}
+		for ; ; next[name]++ {
+			candidate := fmt.Sprintf("%s_%d", name, next[name])
+			if !used[candidate] {
+				used[candidate] = true
+				unique[i] = candidate
+				break
+			}
+		}
+	}
+	return unique, nil
+}
+
+// columnTypes are the types a column can be overridden to with column_types.
+var columnTypes = []string{"string", "number", "bool", "json", "base64"}
+
+// resultColumn describes how a column of a query result is scanned and typed.
+type resultColumn struct {
+	name     string
+	ty       tftypes.Type
+	scanType reflect.Type
+	colType  *sql.ColumnType
+	// decode converts the scanned string for columns whose type is determined from the values,
+	// the column type is then unified across rows by unifyDecodedColumns and ty is only a
+	// fallback for when it cannot be determined from the values
+	decode func(string) (tftypes.Value, error)
+	// unify converts the decoded values of the column in all the rows to a single type, when
+	// it is not set the type is unified from the values by unifyTypes
+	unify func(values []tftypes.Value) (tftypes.Type, error)
+}
+
+// columnsForRows determines the columns of the rows from the column types reported by the
+// driver, so the typing of the result does not depend on the rows returned.
+func (p *provider) columnsForRows(rows *sql.Rows, opts resultOptions) ([]resultColumn, error) {
 	colTypes, err := rows.ColumnTypes()
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to retrieve column type: %w", err)
+		return nil, fmt.Errorf("unable to retrieve column type: %w", err)
 	}
 
-	pointers := make([]interface{}, len(colTypes))
-	row := map[string]struct {
-		index int
-		ty    tftypes.Type
-		val   interface{}
-	}{}
+	names := make([]string, 0, len(colTypes))
+	for _, colType := range colTypes {
+		names = append(names, colType.Name())
+	}
+	names, err = columnNames(names, opts.DuplicateColumns)
+	if err != nil {
+		return nil, err
+	}
 
+	columns := make([]resultColumn, 0, len(colTypes))
 	for i, colType := range colTypes {
-		name := colType.Name()
-		if name == "?column?" {
-			name = fmt.Sprintf("column%d", i)
+		name := names[i]
+
+		if override, ok := opts.ColumnTypes[name]; ok {
+			col, err := columnForOverride(name, override)
+			if err != nil {
+				return nil, err
+			}
+			col.colType = colType
+			columns = append(columns, col)
+			continue
+		}
+
+		if elemType, ok := pgArrayElementType(colType.DatabaseTypeName()); ok && p.Driver == driverPGX {
+			dbName := colType.DatabaseTypeName()
+			columns = append(columns, resultColumn{
+				name:     name,
+				colType:  colType,
+				ty:       tftypes.List{ElementType: elemType},
+				scanType: reflect.TypeOf((*sql.NullString)(nil)).Elem(),
+				decode: func(s string) (tftypes.Value, error) {
+					return pgArrayValue(dbName, elemType, p.binaryEncoding, s)
+				},
+				unify: func(values []tftypes.Value) (tftypes.Type, error) {
+					return pgUnifyArrays(values, elemType)
+				},
+			})
+			continue
+		}
+
+		if opts.DecodeJSON && isJSONColumn(p.Driver, colType.DatabaseTypeName()) {
+			columns = append(columns, resultColumn{
+				name:     name,
+				colType:  colType,
+				ty:       tftypes.String,
+				scanType: reflect.TypeOf((*sql.NullString)(nil)).Elem(),
+				decode: func(s string) (tftypes.Value, error) {
+					return decodeJSONValue([]byte(s))
+				},
+			})
+			continue
+		}
+
+		if p.Driver == driverSQLite && colType.DatabaseTypeName() == "" &&
+			i < len(opts.ExpressionTypes) && opts.ExpressionTypes[i] != nil {
+			columns = append(columns, resultColumn{
+				name:     name,
+				colType:  colType,
+				ty:       opts.ExpressionTypes[i],
+				scanType: reflect.TypeOf((*sqlNumeric)(nil)).Elem(),
+			})
+			continue
 		}
 
 		ty, rty, err := p.typeAndValueForColType(colType)
 		if err != nil {
-			return nil, nil, fmt.Errorf("unable to determine type for %q: %w", name, err)
+			return nil, fmt.Errorf("unable to determine type for %q: %w", name, err)
 		}
 
-		val := reflect.New(rty)
+		columns = append(columns, resultColumn{
+			name:     name,
+			colType:  colType,
+			ty:       ty,
+			scanType: rty,
+		})
+	}
+
+	return columns, nil
+}
+
+// columnForOverride returns the column for a type in column_types, the value is scanned
+// using the driver's conversion to the type instead of the type of the column.
+func columnForOverride(name, override string) (resultColumn, error) {
+	switch override {
+	case "string":
+		return resultColumn{
+			name:     name,
+			ty:       tftypes.String,
+			scanType: reflect.TypeOf((*sql.NullString)(nil)).Elem(),
+		}, nil
+	case "number":
+		return resultColumn{
+			name:     name,
+			ty:       tftypes.Number,
+			scanType: reflect.TypeOf((*sqlNumeric)(nil)).Elem(),
+		}, nil
+	case "bool":
+		return resultColumn{
+			name:     name,
+			ty:       tftypes.Bool,
+			scanType: reflect.TypeOf((*sql.NullBool)(nil)).Elem(),
+		}, nil
+	case "json":
+		return resultColumn{
+			name:     name,
+			ty:       tftypes.String,
+			scanType: reflect.TypeOf((*sql.NullString)(nil)).Elem(),
+			decode: func(s string) (tftypes.Value, error) {
+				return decodeJSONValue([]byte(s))
+			},
+		}, nil
+	case "base64":
+		return resultColumn{
+			name:     name,
+			ty:       tftypes.String,
+			scanType: reflect.TypeOf((*sqlBinary)(nil)).Elem(),
+		}, nil
+	}
+
+	return resultColumn{}, fmt.Errorf("unsupported type %q for column %q", override, name)
+}
+
+// objectTypeForColumns returns the object type of a row with the columns.
+func objectTypeForColumns(columns []resultColumn) tftypes.Object {
+	attrTypes := map[string]tftypes.Type{}
+	for _, col := range columns {
+		attrTypes[col.name] = col.ty
+	}
+	return tftypes.Object{
+		AttributeTypes: attrTypes,
+	}
+}
+
+// columnMetadataType is the type of the elements of the columns attribute of sql_query.
+var columnMetadataType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"position":      tftypes.Number,
+		"name":          tftypes.String,
+		"database_type": tftypes.String,
+		"nullable":      tftypes.Bool,
+		"length":        tftypes.Number,
+		"precision":     tftypes.Number,
+		"scale":         tftypes.Number,
+	},
+}
+
+// columnsMetadataValue returns the metadata of the columns in order, metadata the driver does
+// not report is null.
+func columnsMetadataValue(columns []resultColumn) tftypes.Value {
+	values := make([]tftypes.Value, 0, len(columns))
+	for i, col := range columns {
+		var (
+			nullable  interface{}
+			length    interface{}
+			precision interface{}
+			scale     interface{}
+		)
+		if col.colType != nil {
+			if n, ok := col.colType.Nullable(); ok {
+				nullable = n
+			}
+			if l, ok := col.colType.Length(); ok {
+				length = new(big.Float).SetInt64(l)
+			}
+			if p, s, ok := col.colType.DecimalSize(); ok {
+				precision = new(big.Float).SetInt64(p)
+				scale = new(big.Float).SetInt64(s)
+			}
+		}
+
+		var databaseType interface{}
+		if col.colType != nil && col.colType.DatabaseTypeName() != "" {
+			databaseType = col.colType.DatabaseTypeName()
+		}
+
+		values = append(values, tftypes.NewValue(columnMetadataType, map[string]tftypes.Value{
+			"position":      tftypes.NewValue(tftypes.Number, i+1),
+			"name":          tftypes.NewValue(tftypes.String, col.name),
+			"database_type": tftypes.NewValue(tftypes.String, databaseType),
+			"nullable":      tftypes.NewValue(tftypes.Bool, nullable),
+			"length":        tftypes.NewValue(tftypes.Number, length),
+			"precision":     tftypes.NewValue(tftypes.Number, precision),
+			"scale":         tftypes.NewValue(tftypes.Number, scale),
+		}))
+	}
+	return tftypes.NewValue(tftypes.List{ElementType: columnMetadataType}, values)
+}
+
+func (p *provider) ValuesForRow(rows *sql.Rows, columns []resultColumn) (map[string]tftypes.Value, error) {
+	pointers := make([]interface{}, len(columns))
+	row := map[string]struct {
+		index  int
+		ty     tftypes.Type
+		val    interface{}
+		decode func(string) (tftypes.Value, error)
+	}{}
+
+	for i, col := range columns {
+		val := reflect.New(col.scanType)
 		pointers[i] = val.Interface()
 
-		row[name] = struct {
-			index int
-			ty    tftypes.Type
-			val   interface{}
-		}{i, ty, val.Interface()}
+		row[col.name] = struct {
+			index  int
+			ty     tftypes.Type
+			val    interface{}
+			decode func(string) (tftypes.Value, error)
+		}{i, col.ty, val.Interface(), col.decode}
 	}
 
-	err = rows.Scan(pointers...)
+	err := rows.Scan(pointers...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("unable to scan values: %w", err)
+		return nil, fmt.Errorf("unable to scan values: %w", err)
 	}
 
 	rowValues := map[string]tftypes.Value{}
-	rowTypes := map[string]tftypes.Type{}
 	for k, v := range row {
 		val := v.val
 
@@ -137,7 +472,8 @@ func (p *provider) ValuesForRow(rows *sql.Rows) (map[string]tftypes.Value, map[s
 				val = &tv.Int32
 			}
 		case *sql.NullFloat64:
-			if !tv.Valid {
+			if !tv.Valid || math.IsNaN(tv.Float64) || math.IsInf(tv.Float64, 0) {
+				// NaN and infinity cannot be represented by a Terraform number
 				val = nil
 			} else {
 				val = &tv.Float64
@@ -163,47 +499,88 @@ func (p *provider) ValuesForRow(rows *sql.Rows) (map[string]tftypes.Value, map[s
 			}
 		}
 
+		if v.decode != nil {
+			if val == nil {
+				rowValues[k] = tftypes.NewValue(tftypes.DynamicPseudoType, nil)
+				continue
+			}
+			decoded, err := v.decode(*val.(*string))
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode column %q: %w", k, err)
+			}
+			rowValues[k] = decoded
+			continue
+		}
+
 		rowValues[k] = tftypes.NewValue(
 			v.ty,
 			val,
 		)
-		rowTypes[k] = v.ty
 	}
 
-	return rowValues, rowTypes, nil
+	return rowValues, nil
 }
 
 func (p *provider) typeAndValueForColType(colType *sql.ColumnType) (tftypes.Type, reflect.Type, error) {
 	scanType := colType.ScanType()
-	kind := scanType.Kind()
 
 	switch p.Driver {
-	case "sqlserver":
+	case driverSQLServer:
 		switch dbName := colType.DatabaseTypeName(); dbName {
 		case "UNIQUEIDENTIFIER":
-			return tftypes.String, reflect.TypeOf((*sqlServerUniqueIdentifier)(nil)).Elem(), nil
+			return tftypes.String, reflect.TypeOf((*sqlServerNullUniqueIdentifier)(nil)).Elem(), nil
 		case "DECIMAL", "MONEY", "SMALLMONEY":
-			// TODO: add diags about converting to numeric?
-			return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
+			return p.exactNumericType()
+		case "BINARY", "VARBINARY", "IMAGE":
+			return p.binaryType()
 		}
-	case "mysql":
+	case driverMySQL:
 		switch dbName := colType.DatabaseTypeName(); dbName {
+		case "DECIMAL":
+			return p.exactNumericType()
+		case "BIGINT":
+			// nullable unsigned columns are scanned as int64 by the driver and may overflow
+			return tftypes.Number, reflect.TypeOf((*sqlNumeric)(nil)).Elem(), nil
 		case "YEAR":
 			return tftypes.Number, reflect.TypeOf((*sql.NullInt32)(nil)).Elem(), nil
-		case "VARCHAR", "DECIMAL", "TIME", "JSON":
+		case "BIT":
+			return tftypes.Number, reflect.TypeOf((*mysqlBit)(nil)).Elem(), nil
+		case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY":
+			return p.binaryType()
+		case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM", "SET", "TIME", "JSON", "NULL":
 			return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
 		case "DATE", "DATETIME":
 			return tftypes.String, reflect.TypeOf((*sql.NullTime)(nil)).Elem(), nil
 		}
-	case "pgx":
+	case driverPGX:
 		switch dbName := colType.DatabaseTypeName(); dbName {
-		// 790 is the oid of money
-		case "MONEY", "790":
-			// TODO: add diags about converting to numeric?
+		// 790 is the oid of money, the driver returns numeric values as text so they are not
+		// scanned through a float64
+		case "NUMERIC", "MONEY", "790":
+			return p.exactNumericType()
+		case "JSON", "JSONB":
 			return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
 		case "TIMESTAMPTZ", "TIMESTAMP", "DATE":
 			return tftypes.String, reflect.TypeOf((*sql.NullTime)(nil)).Elem(), nil
+		case "INTERVAL":
+			return tftypes.String, reflect.TypeOf((*pgInterval)(nil)).Elem(), nil
+		case "BYTEA":
+			return p.binaryType()
+		}
+	case driverSQLite:
+		if strings.Contains(strings.ToUpper(colType.DatabaseTypeName()), "BLOB") {
+			return p.binaryType()
 		}
+		if ty, rty, ok := sqliteTypeForDeclType(colType.DatabaseTypeName()); ok {
+			return ty, rty, nil
+		}
+		// the scan type of a column without a declared type depends on the value in the current
+		// row, so it is a string unless the query shows it is a number (see sqliteResultTypes)
+		if scanType != nil && scanType.Kind() == reflect.Slice {
+			// the driver reports [][]byte for blob values
+			return p.binaryType()
+		}
+		return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
 	}
 
 	switch scanType {
@@ -219,7 +596,16 @@ func (p *provider) typeAndValueForColType(colType *sql.ColumnType) (tftypes.Type
 		return tftypes.String, scanType, nil
 	}
 
+	if scanType == nil {
+		return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
+	}
+
+	if scanType == reflect.TypeOf(time.Time{}) {
+		return tftypes.String, reflect.TypeOf((*sql.NullTime)(nil)).Elem(), nil
+	}
+
 	// Force nullable typing for primitives
+	kind := scanType.Kind()
 	switch kind {
 	case reflect.String:
 		return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
@@ -227,13 +613,90 @@ func (p *provider) typeAndValueForColType(colType *sql.ColumnType) (tftypes.Type
 		reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
 		return tftypes.Number, reflect.TypeOf((*sql.NullInt64)(nil)).Elem(), nil
 	case reflect.Uint64:
-		// TODO: uint64 may be a problem in nullint64 if too large?
-		return tftypes.Number, reflect.TypeOf((*sql.NullInt64)(nil)).Elem(), nil
+		// values larger than math.MaxInt64 overflow sql.NullInt64
+		return tftypes.Number, reflect.TypeOf((*sqlNumeric)(nil)).Elem(), nil
 	case reflect.Float32, reflect.Float64:
 		return tftypes.Number, reflect.TypeOf((*sql.NullFloat64)(nil)).Elem(), nil
 	case reflect.Bool:
 		return tftypes.Bool, reflect.TypeOf((*sql.NullBool)(nil)).Elem(), nil
+	case reflect.Slice:
+		if scanType.Elem().Kind() == reflect.Uint8 {
+			return p.binaryType()
+		}
 	}
 
-	return nil, nil, fmt.Errorf("unexpected type for %q: %q (%s %s)", colType.Name(), colType.DatabaseTypeName(), kind, scanType)
+	// fallback to the driver's string conversion, so a single unknown type does not fail the
+	// whole query
+	return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
+}
+
+// exactNumericType returns the type for exact numeric columns (DECIMAL, MONEY, etc) that
+// cannot be represented by a float64, depending on the provider's numeric_mode.
+func (p *provider) exactNumericType() (tftypes.Type, reflect.Type, error) {
+	if p.numericMode == numericModeNumber {
+		return tftypes.Number, reflect.TypeOf((*sqlNumeric)(nil)).Elem(), nil
+	}
+	return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), nil
+}
+
+// binaryType returns the type for binary columns, encoded as a string depending on the
+// provider's binary_encoding.
+func (p *provider) binaryType() (tftypes.Type, reflect.Type, error) {
+	if p.binaryEncoding == binaryEncodingHex {
+		return tftypes.String, reflect.TypeOf((*sqlBinaryHex)(nil)).Elem(), nil
+	}
+	return tftypes.String, reflect.TypeOf((*sqlBinary)(nil)).Elem(), nil
+}
+
+// rowSize approximates the size of a row in the state, see valueSize.
+func rowSize(row map[string]tftypes.Value) int64 {
+	size := int64(2)
+	for name, v := range row {
+		size += int64(len(name)) + 4 + valueSize(v)
+	}
+	return size
+}
+
+// valueSize approximates the size of a value by the size of its JSON encoding.
+func valueSize(v tftypes.Value) int64 {
+	if v.IsNull() || !v.IsKnown() {
+		return 4
+	}
+
+	switch ty := v.Type(); ty.(type) {
+	case tftypes.List, tftypes.Set, tftypes.Tuple:
+		var elems []tftypes.Value
+		if err := v.As(&elems); err != nil {
+			return 0
+		}
+		size := int64(2)
+		for _, elem := range elems {
+			size += valueSize(elem) + 1
+		}
+		return size
+	case tftypes.Map, tftypes.Object:
+		var attrs map[string]tftypes.Value
+		if err := v.As(&attrs); err != nil {
+			return 0
+		}
+		return rowSize(attrs)
+	default:
+		switch {
+		case ty.Is(tftypes.String):
+			var s string
+			if err := v.As(&s); err != nil {
+				return 0
+			}
+			return int64(len(s)) + 2
+		case ty.Is(tftypes.Number):
+			n := &big.Float{}
+			if err := v.As(&n); err != nil {
+				return 0
+			}
+			return int64(len(n.Text('g', -1)))
+		case ty.Is(tftypes.Bool):
+			return 5
+		}
+	}
+	return 0
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -16,7 +17,7 @@ import (
 	"github.com/ory/dockertest/v3"
 )
 
-var rawServerNames = flag.String("server-types", "mysql,postgres,cockroach,sqlserver", "list of server types to run")
+var rawServerNames = flag.String("server-types", "mysql,postgres,cockroach,sqlserver,sqlite", "list of server types to run")
 
 var testServers = []*testServer{
 	{
@@ -104,8 +105,34 @@ var testServers = []*testServer{
 
 		ExpectedDriver: "sqlserver",
 	},
+	{
+		ServerType: "sqlite",
+		NoDocker:   true,
+		StartContainer: func() (*dockertest.Resource, string, error) {
+			dir, err := os.MkdirTemp("", "tftest")
+			if err != nil {
+				return nil, "", err
+			}
+			sqliteTestDir = dir
+
+			url := "sqlite://" + filepath.ToSlash(filepath.Join(dir, "tftest.db"))
+
+			return nil, url, nil
+		},
+
+		OnCleanup: func() {
+			if sqliteTestDir != "" {
+				_ = os.RemoveAll(sqliteTestDir)
+			}
+		},
+
+		ExpectedDriver: "sqlite",
+	},
 }
 
+// sqliteTestDir is the directory of the SQLite test database, it is removed on cleanup
+var sqliteTestDir string
+
 var protoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
 	"sql": func() (tfprotov6.ProviderServer, error) {
 		return New("acctest")(), nil
@@ -146,17 +173,26 @@ func runTestMain(m *testing.M) int {
 		log.Fatalf("no test servers specified")
 	}
 
-	// uses a sensible default on windows (tcp/http) and linux/osx (socket)
-	poolEndpoint := ""
-	if runtime.GOOS == "windows" {
-		poolEndpoint = "npipe:////./pipe/docker_engine"
-	}
-	dockerPool, err = dockertest.NewPool(poolEndpoint)
-	if err != nil {
-		log.Fatalf("could not connect to docker: %s", err)
+	needsDocker := false
+	for _, ts := range testServers {
+		if !ts.NoDocker {
+			needsDocker = true
+		}
 	}
 
-	dockerPool.MaxWait = 5 * time.Minute
+	if needsDocker {
+		// uses a sensible default on windows (tcp/http) and linux/osx (socket)
+		poolEndpoint := ""
+		if runtime.GOOS == "windows" {
+			poolEndpoint = "npipe:////./pipe/docker_engine"
+		}
+		dockerPool, err = dockertest.NewPool(poolEndpoint)
+		if err != nil {
+			log.Fatalf("could not connect to docker: %s", err)
+		}
+
+		dockerPool.MaxWait = 5 * time.Minute
+	}
 
 	for _, driver := range testServers {
 		driver := driver
@@ -174,6 +210,10 @@ type testServer struct {
 	ServerType     string
 	StartContainer func() (*dockertest.Resource, string, error)
 	OnReady        func(*sql.DB) error
+	OnCleanup      func()
+
+	// NoDocker is set for servers that do not run in a container (ie. SQLite)
+	NoDocker bool
 
 	// This is the driver determination expected by the URL scheme
 	ExpectedDriver string
@@ -205,41 +245,48 @@ func (td *testServer) Start() error {
 			return
 		}
 
-		// set a hard expiry on the container for 10 minutes
-		err := td.resource.Expire(10 * 60)
-		if err != nil {
-			log.Printf("unable to set hard expiration: %s", err)
-			// do not exit here, just log the issue
-		}
-
-		td.resourceOnceErr = dockerPool.Retry(func() error {
+		ping := func() error {
 			p := &provider{}
-			err := p.connect(td.url)
+			db, err := p.connect(td.url)
 			if err != nil {
 				return err
 			}
-			defer p.DB.Close()
+			defer db.Close()
 
-			err = p.DB.Ping()
+			err = db.Ping()
 			if err != nil {
 				return err
 			}
 
 			return nil
-		})
+		}
+
+		if td.NoDocker {
+			td.resourceOnceErr = ping()
+		} else {
+			// set a hard expiry on the container for 10 minutes
+			err := td.resource.Expire(10 * 60)
+			if err != nil {
+				log.Printf("unable to set hard expiration: %s", err)
+				// do not exit here, just log the issue
+			}
+
+			td.resourceOnceErr = dockerPool.Retry(ping)
+		}
 		if td.resourceOnceErr != nil {
 			return
 		}
 
 		if td.OnReady != nil {
 			p := &provider{}
-			td.resourceOnceErr = p.connect(td.url)
+			var db *sql.DB
+			db, td.resourceOnceErr = p.connect(td.url)
 			if td.resourceOnceErr != nil {
 				return
 			}
-			defer p.DB.Close()
+			defer db.Close()
 
-			td.resourceOnceErr = td.OnReady(p.DB)
+			td.resourceOnceErr = td.OnReady(db)
 			if td.resourceOnceErr != nil {
 				return
 			}
@@ -253,4 +300,37 @@ func (td *testServer) Cleanup() {
 	if dockerPool != nil && td.resource != nil {
 		dockerPool.Purge(td.resource)
 	}
+	if td.OnCleanup != nil {
+		td.OnCleanup()
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	for name, c := range map[string]struct {
+		names    []string
+		expected []string
+	}{
+		"unique":    {[]string{"id", "name"}, []string{"id", "name"}},
+		"anonymous": {[]string{"id", "?column?", ""}, []string{"id", "column1", "column2"}},
+		"duplicate": {[]string{"id", "name", "id", "id"}, []string{"id", "name", "id_2", "id_3"}},
+		"collision": {[]string{"id", "id", "id_2"}, []string{"id", "id_3", "id_2"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := columnNames(c.names, duplicateColumnsRename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(actual, ",") != strings.Join(c.expected, ",") {
+				t.Fatalf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+
+	_, err := columnNames([]string{"id", "name", "id", "name"}, duplicateColumnsError)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if expected := `duplicate column names in result: "id" at positions 1, 3; "name" at positions 2, 4`; err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
 }
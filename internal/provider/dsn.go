@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// connectionDrivers are the valid values for the provider's `driver` attribute.
+var connectionDrivers = []string{"postgres", "mysql", "sqlserver", "sqlite"}
+
+// connectionConfig is the structured alternative to specifying a connection URL.
+type connectionConfig struct {
+	Driver   string
+	Host     string
+	Port     int64
+	Username string
+	Password string
+	Database string
+	Params   map[string]string
+}
+
+// URL builds a connection URL in the format expected by provider.connect for the
+// configured driver.
+func (c *connectionConfig) URL() (string, error) {
+	switch c.Driver {
+	case "postgres":
+		u := &url.URL{
+			Scheme:   "postgres",
+			User:     c.userinfo(),
+			Host:     c.hostPort(),
+			RawQuery: c.query().Encode(),
+		}
+		if c.Database != "" {
+			u.Path = "/" + c.Database
+		}
+		return u.String(), nil
+	case "mysql":
+		cfg := mysql.NewConfig()
+		cfg.User = c.Username
+		cfg.Passwd = c.Password
+		cfg.Net = "tcp"
+		cfg.Addr = c.hostPort()
+		cfg.DBName = c.Database
+		cfg.Params = c.Params
+		return "mysql://" + cfg.FormatDSN(), nil
+	case "sqlserver":
+		q := c.query()
+		if c.Database != "" {
+			q.Set("database", c.Database)
+		}
+		u := &url.URL{
+			Scheme:   "sqlserver",
+			User:     c.userinfo(),
+			Host:     c.hostPort(),
+			RawQuery: q.Encode(),
+		}
+		return u.String(), nil
+	case "sqlite":
+		if c.Database == "" {
+			return "", fmt.Errorf("a database file is required for sqlite")
+		}
+		dsn := "sqlite://" + c.Database
+		if len(c.Params) > 0 {
+			dsn += "?" + c.query().Encode()
+		}
+		return dsn, nil
+	}
+
+	return "", fmt.Errorf("unexpected driver: %q", c.Driver)
+}
+
+func (c *connectionConfig) userinfo() *url.Userinfo {
+	switch {
+	case c.Username == "" && c.Password == "":
+		return nil
+	case c.Password == "":
+		return url.User(c.Username)
+	}
+	return url.UserPassword(c.Username, c.Password)
+}
+
+func (c *connectionConfig) hostPort() string {
+	if c.Port == 0 {
+		return c.Host
+	}
+	return net.JoinHostPort(c.Host, strconv.FormatInt(c.Port, 10))
+}
+
+func (c *connectionConfig) query() url.Values {
+	q := url.Values{}
+	for k, v := range c.Params {
+		q.Set(k, v)
+	}
+	return q
+}
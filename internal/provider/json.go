@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// isJSONColumn determines if the database type is a JSON document type for the driver.
+func isJSONColumn(driver driverName, databaseTypeName string) bool {
+	switch driver {
+	case driverMySQL:
+		return databaseTypeName == "JSON"
+	case driverPGX:
+		return databaseTypeName == "JSON" || databaseTypeName == "JSONB"
+	case driverSQLite:
+		return strings.EqualFold(databaseTypeName, "JSON")
+	}
+	return false
+}
+
+// decodeJSONValue decodes a JSON document in to a Terraform value. Objects are decoded as
+// objects, arrays as lists if their elements are of one type and otherwise as tuples (ie.
+// `[1, "a"]`), a JSON null (or an empty array) is typed as tftypes.DynamicPseudoType until it
+// is unified with the values in other rows by unifyDecodedColumns.
+func decodeJSONValue(data []byte) (tftypes.Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw interface{}
+	err := dec.Decode(&raw)
+	if err != nil {
+		return tftypes.Value{}, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return tftypes.Value{}, fmt.Errorf("unexpected data after JSON document")
+	}
+
+	return jsonValue(raw)
+}
+
+func jsonValue(raw interface{}) (tftypes.Value, error) {
+	switch raw := raw.(type) {
+	case nil:
+		return tftypes.NewValue(tftypes.DynamicPseudoType, nil), nil
+	case bool:
+		return tftypes.NewValue(tftypes.Bool, raw), nil
+	case string:
+		return tftypes.NewValue(tftypes.String, raw), nil
+	case json.Number:
+		n, _, err := big.ParseFloat(string(raw), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return tftypes.Value{}, fmt.Errorf("unable to parse number %q: %w", raw, err)
+		}
+		return tftypes.NewValue(tftypes.Number, n), nil
+	case []interface{}:
+		elems := make([]tftypes.Value, 0, len(raw))
+		var elemType tftypes.Type = tftypes.DynamicPseudoType
+		for _, rawElem := range raw {
+			elem, err := jsonValue(rawElem)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			elems = append(elems, elem)
+			if elemType == nil {
+				continue
+			}
+			elemType, err = unifyElementTypes(elemType, elem.Type())
+			if err != nil {
+				// the elements differ in type, a tuple keeps the type of each element
+				elemType = nil
+			}
+		}
+		if elemType == nil {
+			elemTypes := make([]tftypes.Type, 0, len(elems))
+			for _, elem := range elems {
+				elemTypes = append(elemTypes, elem.Type())
+			}
+			return tftypes.NewValue(tftypes.Tuple{ElementTypes: elemTypes}, elems), nil
+		}
+		for i, elem := range elems {
+			var err error
+			elems[i], err = convertValue(elem, elemType)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+		}
+		return tftypes.NewValue(tftypes.List{ElementType: elemType}, elems), nil
+	case map[string]interface{}:
+		attrs := make(map[string]tftypes.Value, len(raw))
+		attrTypes := make(map[string]tftypes.Type, len(raw))
+		for k, rawAttr := range raw {
+			attr, err := jsonValue(rawAttr)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			attrs[k] = attr
+			attrTypes[k] = attr.Type()
+		}
+		return tftypes.NewValue(tftypes.Object{AttributeTypes: attrTypes}, attrs), nil
+	}
+
+	return tftypes.Value{}, fmt.Errorf("unexpected JSON value %T", raw)
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestUnifyJSONColumns(t *testing.T) {
+	for name, c := range map[string]struct {
+		docs      []string
+		expected  tftypes.Type
+		expectErr bool
+	}{
+		"primitives": {
+			[]string{`"foo"`, `"bar"`},
+			tftypes.String,
+			false,
+		},
+		"mixed primitives": {
+			[]string{`1`, `"foo"`, `true`},
+			tftypes.String,
+			false,
+		},
+		"objects": {
+			[]string{`{"a": 1}`, `{"b": "foo"}`, `{"a": 2, "b": null}`},
+			tftypes.Object{AttributeTypes: map[string]tftypes.Type{"a": tftypes.Number, "b": tftypes.String}},
+			false,
+		},
+		"nested": {
+			[]string{`{"a": [1, 2]}`, `{"a": [], "b": {"c": true}}`},
+			tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+				"a": tftypes.List{ElementType: tftypes.Number},
+				"b": tftypes.Object{AttributeTypes: map[string]tftypes.Type{"c": tftypes.Bool}},
+			}},
+			false,
+		},
+		"lists": {
+			[]string{`[1, null]`, `[]`, `null`},
+			tftypes.List{ElementType: tftypes.Number},
+			false,
+		},
+		"only nulls": {
+			[]string{`null`, `[]`},
+			tftypes.List{ElementType: tftypes.String},
+			false,
+		},
+		"mixed array": {
+			[]string{`[1, {"a": 1}]`},
+			tftypes.Tuple{ElementTypes: []tftypes.Type{
+				tftypes.Number,
+				tftypes.Object{AttributeTypes: map[string]tftypes.Type{"a": tftypes.Number}},
+			}},
+			false,
+		},
+		"nested mixed arrays": {
+			[]string{`[[1], [{"a": 2}]]`},
+			tftypes.Tuple{ElementTypes: []tftypes.Type{
+				tftypes.List{ElementType: tftypes.Number},
+				tftypes.List{ElementType: tftypes.Object{AttributeTypes: map[string]tftypes.Type{"a": tftypes.Number}}},
+			}},
+			false,
+		},
+		"mixed primitive array": {
+			[]string{`[1, "a"]`},
+			tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.Number, tftypes.String}},
+			false,
+		},
+		"tuples": {
+			[]string{`[1, "a", null]`, `[null, "b", true]`},
+			tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.Number, tftypes.String, tftypes.Bool}},
+			false,
+		},
+		"tuple and list": {
+			[]string{`[1, "a"]`, `[1]`},
+			nil,
+			true,
+		},
+		"object and list": {
+			[]string{`{"a": 1}`, `[1]`},
+			nil,
+			true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			columns := []resultColumn{
+				{
+					name: "doc",
+					ty:   tftypes.String,
+					decode: func(s string) (tftypes.Value, error) {
+						return decodeJSONValue([]byte(s))
+					},
+				},
+			}
+			rows := []map[string]tftypes.Value{}
+			for _, doc := range c.docs {
+				v, err := columns[0].decode(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rows = append(rows, map[string]tftypes.Value{"doc": v})
+			}
+
+			err := unifyDecodedColumns(columns, rows)
+			if c.expectErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !columns[0].ty.Equal(c.expected) {
+				t.Fatalf("expected %s, got %s", c.expected, columns[0].ty)
+			}
+			for i, row := range rows {
+				if !row["doc"].Type().Equal(c.expected) {
+					t.Fatalf("row %d: expected %s, got %s", i, c.expected, row["doc"].Type())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJSONValue(t *testing.T) {
+	v, err := decodeJSONValue([]byte(`{"n": 12345678901234567890.5, "s": "foo", "b": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attrs map[string]tftypes.Value
+	err = v.As(&attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &big.Float{}
+	err = attrs["n"].As(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := n.Text('f', -1); actual != "12345678901234567890.5" {
+		t.Fatalf("expected number to be decoded without loss of precision, got %s", actual)
+	}
+
+	_, err = decodeJSONValue([]byte(`{} {}`))
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+}
@@ -0,0 +1,234 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// lazyDB defers opening the database until it is first used by a resource or data source.
+// This allows the provider to be configured (and things that can plan offline to plan) when
+// the connection configuration is not yet known.
+//
+// All statements are also cancelled when the stop context is done, which happens when
+// Terraform stops the provider (for example on Ctrl-C).
+type lazyDB struct {
+	open func(context.Context) (*sql.DB, error)
+	stop context.Context
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+var (
+	_ dbQueryer  = (*lazyDB)(nil)
+	_ dbExecer   = (*lazyDB)(nil)
+	_ dbPreparer = (*lazyDB)(nil)
+	_ dbBeginner = (*lazyDB)(nil)
+)
+
+// Conn returns the underlying database, opening it if necessary.
+func (l *lazyDB) Conn(ctx context.Context) (*sql.DB, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stop != nil && l.stop.Err() != nil {
+		return nil, fmt.Errorf("unable to open database, the provider is stopping")
+	}
+
+	if l.db != nil {
+		return l.db, nil
+	}
+
+	db, err := l.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l.db = db
+	return l.db, nil
+}
+
+func (l *lazyDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*queryRows, error) {
+	// the context is used while iterating the rows, it is released when the rows are closed
+	ctx, cancel := l.withStop(ctx)
+
+	db, err := l.Conn(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, stopError(l.stop, query, err)
+	}
+	return &queryRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (l *lazyDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := l.withStop(ctx)
+	defer cancel()
+
+	db, err := l.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.ExecContext(ctx, query, args...)
+	return res, stopError(l.stop, query, err)
+}
+
+func (l *lazyDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, cancel := l.withStop(ctx)
+	defer cancel()
+
+	db, err := l.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	return stmt, stopError(l.stop, query, err)
+}
+
+func (l *lazyDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*queryTx, error) {
+	// the context is used for the life of the transaction, it is released when the transaction
+	// is committed or rolled back
+	ctx, cancel := l.withStop(ctx)
+
+	db, err := l.Conn(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		cancel()
+		return nil, stopError(l.stop, "BEGIN", err)
+	}
+	return &queryTx{Tx: tx, stop: l.stop, cancel: cancel}, nil
+}
+
+// withStop derives a context that is also cancelled when the provider is stopped.
+func (l *lazyDB) withStop(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.stop == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		select {
+		case <-l.stop.Done():
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// queryRows are the rows of a query, closing them also releases the context of the query.
+type queryRows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (r *queryRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// queryTx is a transaction, committing or rolling it back also releases its context.
+type queryTx struct {
+	*sql.Tx
+	stop   context.Context
+	cancel context.CancelFunc
+}
+
+var _ dbQueryer = (*queryTx)(nil)
+
+func (tx *queryTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*queryRows, error) {
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, stopError(tx.stop, query, err)
+	}
+	// the context of the transaction is released by the transaction
+	return &queryRows{Rows: rows, cancel: func() {}}, nil
+}
+
+func (tx *queryTx) Commit() error {
+	defer tx.cancel()
+	return tx.Tx.Commit()
+}
+
+func (tx *queryTx) Rollback() error {
+	defer tx.cancel()
+	return tx.Tx.Rollback()
+}
+
+// Close closes the underlying database if it was opened.
+func (l *lazyDB) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.db == nil {
+		return nil
+	}
+
+	err := l.db.Close()
+	l.db = nil
+	return err
+}
+
+// stoppedError is returned when a statement fails because the provider was stopped.
+type stoppedError struct {
+	query string
+	err   error
+}
+
+func (e *stoppedError) Error() string {
+	return fmt.Sprintf("statement cancelled, the provider is stopping: %s", e.err)
+}
+
+func (e *stoppedError) Unwrap() error {
+	return e.err
+}
+
+// stopError wraps err as a stoppedError if the stop context is done.
+func stopError(stop context.Context, query string, err error) error {
+	if err == nil || stop == nil || stop.Err() == nil {
+		return err
+	}
+	var stopped *stoppedError
+	if errors.As(err, &stopped) {
+		return err
+	}
+	return &stoppedError{
+		query: query,
+		err:   err,
+	}
+}
+
+// stoppedDiagnostics returns a diagnostic naming the cancelled statement if err was caused by
+// the provider stopping.
+func stoppedDiagnostics(err error) []*tfprotov6.Diagnostic {
+	var stopped *stoppedError
+	if !errors.As(err, &stopped) {
+		return nil
+	}
+
+	return []*tfprotov6.Diagnostic{
+		{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Statement cancelled",
+			Detail: fmt.Sprintf("Terraform is stopping, the following statement was cancelled and may not have "+
+				"completed:\n\n%s", stopped.query),
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProviderStop(t *testing.T) {
+	p := newProvider()
+	p.url = "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "tftest.db"))
+	p.DB = &lazyDB{
+		open: p.open,
+		stop: p.stopCtx,
+	}
+
+	const query = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT max(x) FROM c"
+
+	ctx := context.Background()
+	_, err := p.DB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		err := p.Stop(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	_, err = p.DB.ExecContext(ctx, query)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+
+	var stopped *stoppedError
+	if !errors.As(err, &stopped) {
+		t.Fatalf("expected stoppedError, got %T: %s", err, err)
+	}
+
+	diags := stoppedDiagnostics(err)
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, query) {
+		t.Fatalf("expected diagnostic naming the statement, got %#v", diags)
+	}
+
+	// the database is not reopened once stopped
+	_, err = p.DB.ExecContext(ctx, "SELECT 1")
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+}
+
+func TestLazyDB_releasesContexts(t *testing.T) {
+	p := newProvider()
+	p.url = "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "tftest.db"))
+	p.DB = &lazyDB{
+		open: p.open,
+		stop: p.stopCtx,
+	}
+	defer p.DB.Close()
+
+	ctx := context.Background()
+	_, err := p.DB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		rows, err := p.DB.QueryContext(ctx, "SELECT 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = rows.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tx, err := p.DB.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tx.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the goroutines watching the stop context exit asynchronously
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at most %d goroutines, got %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
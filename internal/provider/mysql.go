@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// mysqlBit scans a BIT column, the driver returns these as big endian bytes.
+type mysqlBit struct {
+	value *big.Float
+}
+
+func (b *mysqlBit) Scan(v interface{}) error {
+	switch vt := v.(type) {
+	case nil:
+		b.value = nil
+		return nil
+	case []byte:
+		if len(vt) > 8 {
+			return fmt.Errorf("mysql: invalid BIT length %d", len(vt))
+		}
+		var n uint64
+		for _, c := range vt {
+			n = n<<8 | uint64(c)
+		}
+		b.value = new(big.Float).SetUint64(n)
+		return nil
+	case int64:
+		b.value = new(big.Float).SetInt64(vt)
+		return nil
+	}
+
+	return fmt.Errorf("mysql: cannot convert %T to BIT", v)
+}
+
+func (b *mysqlBit) ToTerraform5Value() (interface{}, error) {
+	if b == nil || b.value == nil {
+		return nil, nil
+	}
+	return b.value, nil
+}
+
+// mysqlNoBackslashEscapes returns true if the NO_BACKSLASH_ESCAPES SQL mode is enabled for the
+// session, a backslash is then not an escape character in string literals.
+func mysqlNoBackslashEscapes(ctx context.Context, db dbQueryer) (bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT @@SESSION.sql_mode")
+	if err != nil {
+		return false, fmt.Errorf("unable to read sql_mode: %w", err)
+	}
+	defer rows.Close()
+
+	var mode string
+	if rows.Next() {
+		err = rows.Scan(&mode)
+		if err != nil {
+			return false, fmt.Errorf("unable to read sql_mode: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("unable to read sql_mode: %w", err)
+	}
+
+	for _, m := range strings.Split(mode, ",") {
+		if strings.EqualFold(strings.TrimSpace(m), "NO_BACKSLASH_ESCAPES") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// numericModeString returns exact numeric types (DECIMAL, MONEY, etc) as strings.
+	numericModeString = "string"
+	// numericModeNumber returns exact numeric types as lossless numbers.
+	numericModeNumber = "number"
+)
+
+// sqlNumeric scans an exact numeric value (DECIMAL, NUMERIC, MONEY, large integers) without
+// loss of precision.
+type sqlNumeric struct {
+	value *big.Float
+}
+
+func (n *sqlNumeric) Scan(v interface{}) error {
+	switch vt := v.(type) {
+	case nil:
+		n.value = nil
+		return nil
+	case int64:
+		n.value = new(big.Float).SetInt64(vt)
+		return nil
+	case uint64:
+		n.value = new(big.Float).SetUint64(vt)
+		return nil
+	case float64:
+		if math.IsNaN(vt) || math.IsInf(vt, 0) {
+			// NaN and infinity cannot be represented by a Terraform number
+			n.value = nil
+			return nil
+		}
+		n.value = big.NewFloat(vt)
+		return nil
+	case []byte:
+		return n.parse(string(vt))
+	case string:
+		return n.parse(vt)
+	}
+
+	return fmt.Errorf("cannot convert %T to a number", v)
+}
+
+func (n *sqlNumeric) parse(s string) error {
+	text := strings.TrimSpace(s)
+	if isNonFiniteNumber(text) {
+		// NaN and infinity cannot be represented by a Terraform number
+		n.value = nil
+		return nil
+	}
+
+	f, _, err := big.ParseFloat(text, 10, 512, big.ToNearestEven)
+	if err == nil {
+		n.value = f
+		return nil
+	}
+
+	// money is formatted with a currency symbol and grouping separators, ie. -$1,234.56
+	f, err = parseMoney(text)
+	if err != nil {
+		return fmt.Errorf("unable to parse number %q: %w", s, err)
+	}
+	n.value = f
+	return nil
+}
+
+// moneyAmount matches the digits of a formatted amount, ie. `1,234.56` or `1.234,56`.
+var moneyAmount = regexp.MustCompile(`^[0-9]+([.,][0-9]+)*$`)
+
+// parseMoney parses an amount formatted for a locale, ie. `-$1,234.56`, `($1,234.56)` or
+// `1.234,56 €`. Only a sign (or parentheses for a negative amount), a single currency symbol
+// and grouping separators are allowed in addition to the digits and the decimal separator.
+// When there is only a single separator it is the decimal separator, unless it is a comma
+// followed by three digits (ie. `¥1,234`).
+func parseMoney(s string) (*big.Float, error) {
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	signed, symbol := false, false
+	// the sign and the currency symbol may be in either order before the amount
+	for i := 0; i < 2; i++ {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case (r == '-' || r == '+') && !signed && !negative:
+			signed = true
+			negative = r == '-'
+		case unicode.Is(unicode.Sc, r) && !symbol:
+			symbol = true
+		default:
+			continue
+		}
+		s = strings.TrimSpace(s[size:])
+	}
+	if r, size := utf8.DecodeLastRuneInString(s); unicode.Is(unicode.Sc, r) && !symbol {
+		s = strings.TrimSpace(s[:len(s)-size])
+	}
+
+	if !moneyAmount.MatchString(s) {
+		return nil, fmt.Errorf("unexpected characters in amount %q", s)
+	}
+
+	decimal, group := "", ""
+	lastDot, lastComma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0 && lastDot > lastComma:
+		decimal, group = ".", ","
+	case lastDot >= 0 && lastComma >= 0:
+		decimal, group = ",", "."
+	case lastDot >= 0 && strings.Count(s, ".") > 1:
+		group = "."
+	case lastDot >= 0:
+		decimal = "."
+	case lastComma >= 0 && (strings.Count(s, ",") > 1 || len(s)-lastComma-1 == 3):
+		group = ","
+	case lastComma >= 0:
+		decimal = ","
+	}
+
+	whole, fraction := s, ""
+	if decimal != "" {
+		i := strings.LastIndex(s, decimal)
+		whole, fraction = s[:i], s[i+1:]
+		if strings.Contains(whole, decimal) {
+			return nil, fmt.Errorf("unexpected decimal separator in amount %q", s)
+		}
+	}
+	if group != "" {
+		groups := strings.Split(whole, group)
+		for i, g := range groups {
+			if (i == 0 && len(g) > 3) || (i > 0 && len(g) != 3) {
+				return nil, fmt.Errorf("unexpected grouping in amount %q", s)
+			}
+		}
+		whole = strings.Join(groups, "")
+	}
+	if strings.ContainsAny(fraction, ".,") {
+		return nil, fmt.Errorf("unexpected separator in amount %q", s)
+	}
+
+	text := whole
+	if fraction != "" {
+		text += "." + fraction
+	}
+	if negative {
+		text = "-" + text
+	}
+	f, _, err := big.ParseFloat(text, 10, 512, big.ToNearestEven)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// isNonFiniteNumber reports whether the text is NaN or infinity, these cannot be represented
+// by a Terraform number and are returned as null.
+func isNonFiniteNumber(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "nan", "inf", "+inf", "-inf", "infinity", "+infinity", "-infinity":
+		return true
+	}
+	return false
+}
+
+func (n *sqlNumeric) ToTerraform5Value() (interface{}, error) {
+	if n == nil || n.value == nil {
+		return nil, nil
+	}
+	return n.value, nil
+}
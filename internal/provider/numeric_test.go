@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestSQLNumericScan(t *testing.T) {
+	for name, c := range map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"null":                     {nil, ""},
+		"decimal":                  {[]byte("12345678901234567890.125"), "12345678901234567890.125"},
+		"negative":                 {"-0.001", "-0.001"},
+		"money":                    {"-$1,234.56", "-1234.56"},
+		"money sign after symbol":  {"$-1,234.56", "-1234.56"},
+		"money parentheses":        {"($1.00)", "-1"},
+		"money decimal comma":      {"1.234,56 €", "1234.56"},
+		"money no fraction":        {"¥1,234,567", "1234567"},
+		"money comma fraction":     {"-1,5 €", "-1.5"},
+		"money space after symbol": {"$ 1,000.00", "1000"},
+		"nan":                      {"NaN", ""},
+		"infinity":                 {"-Infinity", ""},
+		"float64 nan":              {math.NaN(), ""},
+		"float64 infinity":         {math.Inf(1), ""},
+		"int64":                    {int64(math.MinInt64), strconv.FormatInt(math.MinInt64, 10)},
+		"uint64":                   {uint64(math.MaxUint64), strconv.FormatUint(math.MaxUint64, 10)},
+		"uint64 text":              {[]byte("18446744073709551615"), "18446744073709551615"},
+		"float64":                  {0.125, "0.125"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			n := &sqlNumeric{}
+			err := n.Scan(c.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := ""
+			if n.value != nil {
+				actual = n.value.Text('f', -1)
+			}
+			if actual != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"abc", "1-2", "$1,23.00", "1.234.5,6,7", "$$1", "--1", "(-$1)", "1,234.56 kr"} {
+		err := (&sqlNumeric{}).Scan(invalid)
+		if err == nil {
+			t.Fatalf("expected error for %q but got none", invalid)
+		}
+	}
+	err := (&sqlNumeric{}).Scan(true)
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+}
@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// queryParameters are the values bound to a query, either by position or by name.
+type queryParameters struct {
+	Positional []interface{}
+	Named      map[string]interface{}
+}
+
+// parametersFromValue reads the `parameters` attribute, a list or tuple is bound by position
+// and a map or object is bound by name.
+func parametersFromValue(v tftypes.Value) (*queryParameters, error) {
+	params := &queryParameters{}
+	if v.IsNull() {
+		return params, nil
+	}
+
+	switch ty := v.Type(); {
+	case ty.Is(tftypes.List{}), ty.Is(tftypes.Tuple{}), ty.Is(tftypes.Set{}):
+		var elems []tftypes.Value
+		err := v.As(&elems)
+		if err != nil {
+			return nil, err
+		}
+		for i, elem := range elems {
+			arg, err := parameterFromValue(elem)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+			params.Positional = append(params.Positional, arg)
+		}
+	case ty.Is(tftypes.Map{}), ty.Is(tftypes.Object{}):
+		var elems map[string]tftypes.Value
+		err := v.As(&elems)
+		if err != nil {
+			return nil, err
+		}
+		params.Named = map[string]interface{}{}
+		for name, elem := range elems {
+			arg, err := parameterFromValue(elem)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %q: %w", name, err)
+			}
+			params.Named[name] = arg
+		}
+	default:
+		return nil, fmt.Errorf("parameters must be a list (positional) or an object (named), got %s", ty)
+	}
+
+	return params, nil
+}
+
+func parameterFromValue(v tftypes.Value) (interface{}, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+
+	switch ty := v.Type(); {
+	case ty.Is(tftypes.String):
+		var s string
+		err := v.As(&s)
+		return s, err
+	case ty.Is(tftypes.Bool):
+		var b bool
+		err := v.As(&b)
+		return b, err
+	case ty.Is(tftypes.Number):
+		n := &big.Float{}
+		err := v.As(&n)
+		if err != nil {
+			return nil, err
+		}
+		if n.IsInt() {
+			if i, acc := n.Int64(); acc == big.Exact {
+				return i, nil
+			}
+			// integers outside of the int64 range (ie. unsigned 64-bit ids) would lose
+			// precision as a float64, they are bound as a string for the database to convert
+			return n.Text('f', -1), nil
+		}
+		f, _ := n.Float64()
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s, only strings, numbers and bools can be bound", v.Type())
+}
+
+// bindParameters translates the portable placeholders in the query to the driver's
+// placeholder syntax and returns the matching arguments.
+//
+// Positional parameters are referenced as `$1`, `$2`, etc and named parameters as `:name`.
+// Placeholders in string literals, quoted identifiers and comments are ignored. If the query
+// contains no portable placeholders, the positional parameters are passed through unchanged
+// so the driver's native placeholders can also be used.
+func bindParameters(driver driverName, query string, params *queryParameters) (string, []interface{}, error) {
+	if len(params.Positional) == 0 && len(params.Named) == 0 {
+		// leave queries without parameters untouched
+		return query, nil, nil
+	}
+
+	var (
+		sb   strings.Builder
+		args []interface{}
+		// index of each distinct placeholder in args, for drivers with numbered placeholders
+		indexes = map[string]int{}
+		found   bool
+	)
+
+	err := scanPlaceholders(driver, query, func(text string, placeholder bool) error {
+		if !placeholder {
+			sb.WriteString(text)
+			return nil
+		}
+		found = true
+
+		var arg interface{}
+		if strings.HasPrefix(text, "$") {
+			n, _ := strconv.Atoi(text[1:])
+			if n < 1 || n > len(params.Positional) {
+				return fmt.Errorf("parameter %s is not set, %d positional parameter(s) provided", text, len(params.Positional))
+			}
+			arg = params.Positional[n-1]
+		} else {
+			v, ok := params.Named[text[1:]]
+			if !ok {
+				return fmt.Errorf("parameter %s is not set", text)
+			}
+			arg = v
+		}
+
+		if driver == driverMySQL {
+			// mysql only supports unnumbered placeholders, bind each occurrence
+			args = append(args, arg)
+			sb.WriteString("?")
+			return nil
+		}
+
+		i, ok := indexes[text]
+		if !ok {
+			args = append(args, arg)
+			i = len(args)
+			indexes[text] = i
+		}
+
+		switch driver {
+		case driverSQLServer:
+			fmt.Fprintf(&sb, "@p%d", i)
+		case driverSQLite:
+			fmt.Fprintf(&sb, "?%d", i)
+		default:
+			fmt.Fprintf(&sb, "$%d", i)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !found {
+		if len(params.Named) > 0 {
+			return "", nil, fmt.Errorf("named parameters are set but the query has no `:name` placeholders")
+		}
+		return query, params.Positional, nil
+	}
+
+	return sb.String(), args, nil
+}
+
+// scanPlaceholders splits the query in to placeholder and non-placeholder text, skipping
+// string literals, quoted identifiers and comments.
+func scanPlaceholders(driver driverName, query string, f func(text string, placeholder bool) error) error {
+	start := 0
+	flush := func(end int) error {
+		if end <= start {
+			return nil
+		}
+		err := f(query[start:end], false)
+		start = end
+		return err
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || (c == '[' && driver == driverSQLServer):
+			i = skipQuoted(driver, query, i)
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == ':' && strings.HasPrefix(query[i:], "::"):
+			// postgres cast
+			i += 2
+		case c == '$' && (i == 0 || !isIdentByte(query[i-1])):
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				if err := flush(i); err != nil {
+					return err
+				}
+				if err := f(query[i:j], true); err != nil {
+					return err
+				}
+				start = j
+				i = j
+				continue
+			}
+			if driver == driverPGX {
+				i = skipDollarQuoted(query, i)
+				continue
+			}
+			i++
+		case c == ':' && (i == 0 || !isIdentByte(query[i-1])) && i+1 < len(query) && isIdentStartByte(query[i+1]):
+			j := i + 1
+			for j < len(query) && isIdentByte(query[j]) {
+				j++
+			}
+			if err := flush(i); err != nil {
+				return err
+			}
+			if err := f(query[i:j], true); err != nil {
+				return err
+			}
+			start = j
+			i = j
+		default:
+			i++
+		}
+	}
+
+	return flush(len(query))
+}
+
+// splitStatements returns the statements of a batch, split at each semicolon that is not in a
+// string literal, quoted identifier or comment. The semicolons are not included and text that
+// is only whitespace or comments is omitted.
+func splitStatements(driver driverName, query string) []string {
+	var (
+		statements []string
+		start      int
+		hasContent bool
+	)
+	flush := func(end int) {
+		if hasContent {
+			statements = append(statements, query[start:end])
+		}
+		hasContent = false
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || (c == '[' && (driver == driverSQLServer || driver == driverSQLite)):
+			hasContent = true
+			i = skipQuoted(driver, query, i)
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && driver == driverMySQL:
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == '$' && driver == driverPGX:
+			hasContent = true
+			i = skipDollarQuoted(query, i)
+		case c == ';':
+			flush(i)
+			i++
+			start = i
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		default:
+			hasContent = true
+			i++
+		}
+	}
+	flush(len(query))
+
+	return statements
+}
+
+// skipQuoted returns the index after the quoted string or identifier starting at i.
+func skipQuoted(driver driverName, query string, i int) int {
+	open := query[i]
+	closing := open
+	if open == '[' {
+		closing = ']'
+	}
+
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if driver == driverMySQL && open != '`' {
+				j++
+			}
+		case closing:
+			// doubled quotes are an escaped quote
+			if j+1 < len(query) && query[j+1] == closing {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// skipDollarQuoted returns the index after the postgres dollar quoted string ($$...$$ or
+// $tag$...$tag$) starting at i, or i+1 if it is not a dollar quoted string.
+func skipDollarQuoted(query string, i int) int {
+	j := i + 1
+	for j < len(query) && (isIdentStartByte(query[j]) || (query[j] >= '0' && query[j] <= '9')) {
+		j++
+	}
+	if j >= len(query) || query[j] != '$' {
+		return i + 1
+	}
+
+	tag := query[i : j+1]
+	end := strings.Index(query[j+1:], tag)
+	if end < 0 {
+		return len(query)
+	}
+	return j + 1 + end + len(tag)
+}
+
+func isIdentStartByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStartByte(c) || (c >= '0' && c <= '9') || c == '$'
+}
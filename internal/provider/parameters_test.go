@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestBindParameters(t *testing.T) {
+	positional := &queryParameters{
+		Positional: []interface{}{"a", int64(2)},
+	}
+	named := &queryParameters{
+		Named: map[string]interface{}{"name": "a", "id": int64(2)},
+	}
+
+	for name, c := range map[string]struct {
+		driver       driverName
+		query        string
+		params       *queryParameters
+		expected     string
+		expectedArgs []interface{}
+		expectErr    bool
+	}{
+		"no parameters":  {driverPGX, "select $1, :name", &queryParameters{}, "select $1, :name", nil, false},
+		"pgx positional": {driverPGX, "select $2, $1, $2", positional, "select $1, $2, $1", []interface{}{int64(2), "a"}, false},
+		"pgx named":      {driverPGX, "select :name, :id, :name", named, "select $1, $2, $1", []interface{}{"a", int64(2)}, false},
+		"mysql positional": {
+			driverMySQL, "select $2, $1, $2", positional, "select ?, ?, ?", []interface{}{int64(2), "a", int64(2)}, false,
+		},
+		"mysql named":          {driverMySQL, "select :id, :name", named, "select ?, ?", []interface{}{int64(2), "a"}, false},
+		"sqlserver positional": {driverSQLServer, "select $1, $2", positional, "select @p1, @p2", positional.Positional, false},
+		"sqlserver named":      {driverSQLServer, "select :name", named, "select @p1", []interface{}{"a"}, false},
+		"sqlite positional":    {driverSQLite, "select $2, $1", positional, "select ?1, ?2", []interface{}{int64(2), "a"}, false},
+
+		"native placeholders": {driverMySQL, "select ?, ?", positional, "select ?, ?", positional.Positional, false},
+
+		"string literal":     {driverPGX, "select '$1 :name', $1", positional, "select '$1 :name', $1", []interface{}{"a"}, false},
+		"escaped quote":      {driverPGX, "select 'it''s $1', $2", positional, "select 'it''s $1', $1", []interface{}{int64(2)}, false},
+		"mysql escape":       {driverMySQL, `select 'it\'s $1', $2`, positional, `select 'it\'s $1', ?`, []interface{}{int64(2)}, false},
+		"quoted identifier":  {driverPGX, `select 1 as ":id", :id`, named, `select 1 as ":id", $1`, []interface{}{int64(2)}, false},
+		"bracket identifier": {driverSQLServer, "select 1 as [:id], :id", named, "select 1 as [:id], @p1", []interface{}{int64(2)}, false},
+		"line comment":       {driverPGX, "select $2 -- $1\n", positional, "select $1 -- $1\n", []interface{}{int64(2)}, false},
+		"block comment":      {driverPGX, "select /* :name */ :id", named, "select /* :name */ $1", []interface{}{int64(2)}, false},
+		"cast":               {driverPGX, "select :id::text", named, "select $1::text", []interface{}{int64(2)}, false},
+		"dollar quoted":      {driverPGX, "select $$ $1 $$, $tag$ :id $tag$, $2", positional, "select $$ $1 $$, $tag$ :id $tag$, $1", []interface{}{int64(2)}, false},
+		"identifier dollar":  {driverMySQL, "select a$1, $1 from t", positional, "select a$1, ? from t", []interface{}{"a"}, false},
+
+		"positional out of range": {driverPGX, "select $3", positional, "", nil, true},
+		"named not set":           {driverPGX, "select :missing", named, "", nil, true},
+		"named unused":            {driverPGX, "select 1", named, "", nil, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, args, err := bindParameters(c.driver, c.query, c.params)
+			if c.expectErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != c.expected {
+				t.Fatalf("expected query %q, got %q", c.expected, actual)
+			}
+			if !reflect.DeepEqual(args, c.expectedArgs) {
+				t.Fatalf("expected args %#v, got %#v", c.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestParameterFromValue(t *testing.T) {
+	maxUint64, _ := new(big.Float).SetPrec(512).SetString("18446744073709551615")
+
+	for name, c := range map[string]struct {
+		value    tftypes.Value
+		expected interface{}
+	}{
+		"null":           {tftypes.NewValue(tftypes.String, nil), nil},
+		"string":         {tftypes.NewValue(tftypes.String, "a"), "a"},
+		"bool":           {tftypes.NewValue(tftypes.Bool, true), true},
+		"int64":          {tftypes.NewValue(tftypes.Number, big.NewFloat(-42)), int64(-42)},
+		"float":          {tftypes.NewValue(tftypes.Number, big.NewFloat(1.5)), 1.5},
+		"above MaxInt64": {tftypes.NewValue(tftypes.Number, maxUint64), "18446744073709551615"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parameterFromValue(c.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Fatalf("expected %#v, got %#v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	for name, c := range map[string]struct {
+		driver   driverName
+		query    string
+		expected []string
+	}{
+		"single":           {driverSQLite, "select 1", []string{"select 1"}},
+		"trailing":         {driverSQLite, "select 1;\n", []string{"select 1"}},
+		"batch":            {driverSQLite, "create table t (id integer); select id from t;", []string{"create table t (id integer)", " select id from t"}},
+		"quoted":           {driverSQLite, "select ';' as \"a;b\", [c;d]; select 2", []string{"select ';' as \"a;b\", [c;d]", " select 2"}},
+		"comments":         {driverSQLite, "-- a; b\nselect 1; /* c; */", []string{"-- a; b\nselect 1"}},
+		"empty":            {driverSQLite, "", nil},
+		"semicolon":        {driverSQLite, ";", nil},
+		"mysql comment":    {driverMySQL, "select 1 # a; b", []string{"select 1 # a; b"}},
+		"mysql escape":     {driverMySQL, `select 'a\';b'`, []string{`select 'a\';b'`}},
+		"postgres dollar":  {driverPGX, "do $$ begin perform 1; end $$", []string{"do $$ begin perform 1; end $$"}},
+		"postgres param":   {driverPGX, "select $1; select $2", []string{"select $1", " select $2"}},
+		"sqlserver quoted": {driverSQLServer, "select 1 as [a;b]; select 2", []string{"select 1 as [a;b]", " select 2"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual := splitStatements(c.driver, c.query)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Fatalf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
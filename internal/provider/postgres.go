@@ -0,0 +1,331 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// pgArrayTypes are the array types returned as lists, some of these are not registered by
+// pgtype, registering them also reports the type name (instead of the OID) for the column.
+var pgArrayTypes = map[string]uint32{
+	"_aclitem":     pgtype.ACLItemArrayOID,
+	"_bool":        pgtype.BoolArrayOID,
+	"_bpchar":      pgtype.BPCharArrayOID,
+	"_bytea":       pgtype.ByteaArrayOID,
+	"_char":        1002,
+	"_cidr":        pgtype.CIDRArrayOID,
+	"_date":        pgtype.DateArrayOID,
+	"_float4":      pgtype.Float4ArrayOID,
+	"_float8":      pgtype.Float8ArrayOID,
+	"_inet":        pgtype.InetArrayOID,
+	"_int2":        pgtype.Int2ArrayOID,
+	"_int4":        pgtype.Int4ArrayOID,
+	"_int8":        pgtype.Int8ArrayOID,
+	"_interval":    1187,
+	"_json":        pgtype.JSONArrayOID,
+	"_jsonb":       pgtype.JSONBArrayOID,
+	"_macaddr":     1040,
+	"_name":        1003,
+	"_numeric":     pgtype.NumericArrayOID,
+	"_oid":         1028,
+	"_text":        pgtype.TextArrayOID,
+	"_time":        1183,
+	"_timestamp":   pgtype.TimestampArrayOID,
+	"_timestamptz": pgtype.TimestamptzArrayOID,
+	"_uuid":        pgtype.UUIDArrayOID,
+	"_varchar":     pgtype.VarcharArrayOID,
+}
+
+// pgTextTypes are the scalar types that are returned in the text format, the stdlib driver
+// scans types it does not know to a string, which fails for these in the binary format.
+var pgTextTypes = map[string]uint32{
+	"cidr":     pgtype.CIDROID,
+	"inet":     pgtype.InetOID,
+	"interval": pgtype.IntervalOID,
+	"macaddr":  pgtype.MacaddrOID,
+	"macaddr8": 774,
+	"uuid":     pgtype.UUIDOID,
+}
+
+// pgTypesAsText is run after each new pgx connection. It registers the array types and
+// pgTextTypes as text so the server returns them in the text format, the stdlib driver passes
+// text format values through as strings which are then parsed by pgArrayValue (or returned as
+// is). Arrays in the binary format cannot be scanned to a string.
+func pgTypesAsText(_ context.Context, conn *pgx.Conn) error {
+	ci := conn.ConnInfo()
+	for _, types := range []map[string]uint32{pgArrayTypes, pgTextTypes} {
+		for name, oid := range types {
+			ci.RegisterDataType(pgtype.DataType{
+				Value: &pgtype.GenericText{},
+				Name:  name,
+				OID:   oid,
+			})
+		}
+	}
+	return nil
+}
+
+// pgInterval scans an interval in the postgres text format and converts it to an ISO 8601
+// duration, ie. `P1Y2M3DT4H5M6S`.
+type pgInterval struct {
+	value *string
+}
+
+func (i *pgInterval) Scan(v interface{}) error {
+	var text string
+	switch vt := v.(type) {
+	case nil:
+		i.value = nil
+		return nil
+	case string:
+		text = vt
+	case []byte:
+		text = string(vt)
+	default:
+		return fmt.Errorf("cannot convert %T to an interval", v)
+	}
+
+	if strings.HasPrefix(text, "P") {
+		// already ISO 8601 (intervalstyle is iso_8601)
+		i.value = &text
+		return nil
+	}
+
+	var interval pgtype.Interval
+	err := interval.DecodeText(nil, []byte(text))
+	if err != nil {
+		return fmt.Errorf("unable to parse interval %q: %w", text, err)
+	}
+
+	s := isoDuration(interval.Months, interval.Days, interval.Microseconds)
+	i.value = &s
+	return nil
+}
+
+func (i *pgInterval) ToTerraform5Value() (interface{}, error) {
+	if i == nil {
+		return nil, nil
+	}
+	return i.value, nil
+}
+
+// isoDuration formats an interval as an ISO 8601 duration, the components are signed
+// individually the same as the postgres iso_8601 interval style.
+func isoDuration(months, days int32, microseconds int64) string {
+	if months == 0 && days == 0 && microseconds == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString("P")
+	if years := months / 12; years != 0 {
+		fmt.Fprintf(&b, "%dY", years)
+	}
+	if months%12 != 0 {
+		fmt.Fprintf(&b, "%dM", months%12)
+	}
+	if days != 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if microseconds == 0 {
+		return b.String()
+	}
+
+	b.WriteString("T")
+	const microsecondsPerHour = int64(time.Hour / time.Microsecond)
+	const microsecondsPerMinute = int64(time.Minute / time.Microsecond)
+	if hours := microseconds / microsecondsPerHour; hours != 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+	if minutes := microseconds % microsecondsPerHour / microsecondsPerMinute; minutes != 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if seconds := microseconds % microsecondsPerMinute; seconds != 0 {
+		s := strconv.FormatFloat(float64(seconds)/1e6, 'f', -1, 64)
+		fmt.Fprintf(&b, "%sS", s)
+	}
+	return b.String()
+}
+
+// pgArrayElementType returns the Terraform type for the elements of an array column.
+func pgArrayElementType(databaseTypeName string) (tftypes.Type, bool) {
+	name := strings.ToLower(databaseTypeName)
+	if _, ok := pgArrayTypes[name]; !ok {
+		return nil, false
+	}
+
+	switch strings.TrimPrefix(name, "_") {
+	case "int2", "int4", "int8", "oid", "float4", "float8", "numeric":
+		return tftypes.Number, true
+	case "bool":
+		return tftypes.Bool, true
+	}
+	return tftypes.String, true
+}
+
+// pgArrayValue parses an array in the postgres text format in to a list, multi-dimensional
+// arrays are returned as nested lists. Empty arrays are typed as a list of
+// tftypes.DynamicPseudoType as they have no dimensions, see pgUnifyArrays. Binary elements are
+// encoded with the binary encoding, the same as scalar binary columns.
+func pgArrayValue(databaseTypeName string, elemType tftypes.Type, binaryEncoding string, s string) (tftypes.Value, error) {
+	arr, err := pgtype.ParseUntypedTextArray(s)
+	if err != nil {
+		return tftypes.Value{}, err
+	}
+
+	if len(arr.Dimensions) == 0 {
+		return tftypes.NewValue(tftypes.List{ElementType: tftypes.DynamicPseudoType}, []tftypes.Value{}), nil
+	}
+
+	elemName := strings.TrimPrefix(strings.ToLower(databaseTypeName), "_")
+	elems := make([]tftypes.Value, 0, len(arr.Elements))
+	for i, text := range arr.Elements {
+		if !arr.Quoted[i] && strings.EqualFold(text, "NULL") {
+			elems = append(elems, tftypes.NewValue(elemType, nil))
+			continue
+		}
+
+		elem, err := pgArrayElementValue(elemName, elemType, binaryEncoding, text)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		elems = append(elems, elem)
+	}
+
+	return pgNestArray(arr.Dimensions, elems, elemType), nil
+}
+
+func pgArrayElementValue(elemName string, elemType tftypes.Type, binaryEncoding string, text string) (tftypes.Value, error) {
+	switch {
+	case elemType.Is(tftypes.Number) && isNonFiniteNumber(text):
+		// NaN and infinity cannot be represented by a Terraform number
+		return tftypes.NewValue(tftypes.Number, nil), nil
+	case elemType.Is(tftypes.Number):
+		n, _, err := big.ParseFloat(text, 10, 512, big.ToNearestEven)
+		if err != nil {
+			return tftypes.Value{}, fmt.Errorf("unable to parse array element %q: %w", text, err)
+		}
+		return tftypes.NewValue(tftypes.Number, n), nil
+	case elemType.Is(tftypes.Bool):
+		return tftypes.NewValue(tftypes.Bool, text == "t"), nil
+	}
+
+	switch elemName {
+	case "date", "timestamp", "timestamptz":
+		// match the formatting of scalar date and time columns
+		for _, layout := range []string{
+			"2006-01-02",
+			"2006-01-02 15:04:05.999999999",
+			"2006-01-02 15:04:05.999999999Z07",
+			"2006-01-02 15:04:05.999999999Z07:00",
+			"2006-01-02 15:04:05.999999999Z07:00:00",
+		} {
+			if t, err := time.Parse(layout, text); err == nil {
+				return tftypes.NewValue(tftypes.String, t.UTC().Format(time.RFC3339)), nil
+			}
+		}
+	case "interval":
+		// match the ISO 8601 formatting of scalar interval columns
+		interval := &pgInterval{}
+		err := interval.Scan(text)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		return tftypes.NewValue(tftypes.String, interval), nil
+	case "bytea":
+		// match the binary_encoding of scalar bytea columns
+		var bytea pgtype.Bytea
+		err := bytea.DecodeText(nil, []byte(text))
+		if err != nil {
+			return tftypes.Value{}, fmt.Errorf("unable to parse array element %q: %w", text, err)
+		}
+		binary := sqlBinary{value: bytea.Bytes, valid: true}
+		if binaryEncoding == binaryEncodingHex {
+			return tftypes.NewValue(tftypes.String, &sqlBinaryHex{binary}), nil
+		}
+		return tftypes.NewValue(tftypes.String, &binary), nil
+	}
+
+	return tftypes.NewValue(tftypes.String, text), nil
+}
+
+// pgNestArray nests the flattened elements of an array in lists by its dimensions.
+func pgNestArray(dims []pgtype.ArrayDimension, elems []tftypes.Value, elemType tftypes.Type) tftypes.Value {
+	if len(dims) == 1 {
+		return tftypes.NewValue(tftypes.List{ElementType: elemType}, elems)
+	}
+
+	var innerType tftypes.Type = elemType
+	for range dims[1:] {
+		innerType = tftypes.List{ElementType: innerType}
+	}
+
+	length := int(dims[0].Length)
+	size := len(elems) / length
+	values := make([]tftypes.Value, 0, length)
+	for i := 0; i < length; i++ {
+		values = append(values, pgNestArray(dims[1:], elems[i*size:(i+1)*size], elemType))
+	}
+
+	return tftypes.NewValue(tftypes.List{ElementType: innerType}, values)
+}
+
+// pgUnifyArrays nests the arrays of a column to the same depth and returns the type of the
+// column. Postgres does not enforce the dimensions of an array column (and does not report
+// them in the result), so the depth is the most dimensions of any array in the column and
+// arrays with fewer dimensions are nested in single element lists. Empty arrays and nulls
+// take the depth of the column, which is one dimension if there are no other values.
+func pgUnifyArrays(values []tftypes.Value, elemType tftypes.Type) (tftypes.Type, error) {
+	depth := 1
+	for _, v := range values {
+		if d := pgArrayDepth(v.Type()); d > depth {
+			depth = d
+		}
+	}
+
+	ty := elemType
+	for i := 0; i < depth; i++ {
+		ty = tftypes.List{ElementType: ty}
+	}
+
+	for i, v := range values {
+		switch d := pgArrayDepth(v.Type()); {
+		case v.IsNull():
+			values[i] = tftypes.NewValue(ty, nil)
+		case d == 0:
+			values[i] = tftypes.NewValue(ty, []tftypes.Value{})
+		default:
+			for ; d < depth; d++ {
+				v = tftypes.NewValue(tftypes.List{ElementType: v.Type()}, []tftypes.Value{v})
+			}
+			if !v.Type().Equal(ty) {
+				return nil, fmt.Errorf("unexpected array type %s, expected %s", v.Type(), ty)
+			}
+			values[i] = v
+		}
+	}
+
+	return ty, nil
+}
+
+// pgArrayDepth returns the number of dimensions of a decoded array, 0 for empty arrays and
+// nulls.
+func pgArrayDepth(ty tftypes.Type) int {
+	depth := 0
+	for ty.Is(tftypes.List{}) {
+		depth++
+		ty = ty.(tftypes.List).ElementType
+	}
+	if ty.Is(tftypes.DynamicPseudoType) {
+		return 0
+	}
+	return depth
+}
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestPGArrayValue(t *testing.T) {
+	number := func(f float64) tftypes.Value {
+		return tftypes.NewValue(tftypes.Number, big.NewFloat(f))
+	}
+	str := func(s string) tftypes.Value {
+		return tftypes.NewValue(tftypes.String, s)
+	}
+	numberList := tftypes.List{ElementType: tftypes.Number}
+	stringList := tftypes.List{ElementType: tftypes.String}
+
+	for name, c := range map[string]struct {
+		databaseTypeName string
+		binaryEncoding   string
+		text             string
+		expected         tftypes.Value
+	}{
+		"int4": {
+			"_INT4", binaryEncodingBase64, "{1,2,3}",
+			tftypes.NewValue(numberList, []tftypes.Value{number(1), number(2), number(3)}),
+		},
+		"null elements": {
+			"_INT4", binaryEncodingBase64, "{1,NULL}",
+			tftypes.NewValue(numberList, []tftypes.Value{number(1), tftypes.NewValue(tftypes.Number, nil)}),
+		},
+		"text": {
+			"_TEXT", binaryEncodingBase64, `{foo,"bar baz","NULL",NULL}`,
+			tftypes.NewValue(stringList, []tftypes.Value{str("foo"), str("bar baz"), str("NULL"), tftypes.NewValue(tftypes.String, nil)}),
+		},
+		"bool": {
+			"_BOOL", binaryEncodingBase64, "{t,f}",
+			tftypes.NewValue(tftypes.List{ElementType: tftypes.Bool}, []tftypes.Value{
+				tftypes.NewValue(tftypes.Bool, true),
+				tftypes.NewValue(tftypes.Bool, false),
+			}),
+		},
+		"timestamptz": {
+			"_TIMESTAMPTZ", binaryEncodingBase64, `{"1999-01-08 04:05:06-08"}`,
+			tftypes.NewValue(stringList, []tftypes.Value{str("1999-01-08T12:05:06Z")}),
+		},
+		"multi-dimensional": {
+			"_INT4", binaryEncodingBase64, "{{1,2},{3,4},{5,6}}",
+			tftypes.NewValue(tftypes.List{ElementType: numberList}, []tftypes.Value{
+				tftypes.NewValue(numberList, []tftypes.Value{number(1), number(2)}),
+				tftypes.NewValue(numberList, []tftypes.Value{number(3), number(4)}),
+				tftypes.NewValue(numberList, []tftypes.Value{number(5), number(6)}),
+			}),
+		},
+		"non-finite": {
+			"_FLOAT8", binaryEncodingBase64, "{1,NaN,-Infinity}",
+			tftypes.NewValue(numberList, []tftypes.Value{number(1), tftypes.NewValue(tftypes.Number, nil), tftypes.NewValue(tftypes.Number, nil)}),
+		},
+		"interval": {
+			"_INTERVAL", binaryEncodingBase64, `{"1 year 2 mons 3 days 04:05:06.5",NULL}`,
+			tftypes.NewValue(stringList, []tftypes.Value{str("P1Y2M3DT4H5M6.5S"), tftypes.NewValue(tftypes.String, nil)}),
+		},
+		"bytea": {
+			"_BYTEA", binaryEncodingBase64, `{"\\xdeadbeef",NULL}`,
+			tftypes.NewValue(stringList, []tftypes.Value{str("3q2+7w=="), tftypes.NewValue(tftypes.String, nil)}),
+		},
+		"bytea hex": {
+			"_BYTEA", binaryEncodingHex, `{"\\xdeadbeef"}`,
+			tftypes.NewValue(stringList, []tftypes.Value{str("deadbeef")}),
+		},
+		"empty": {
+			"_INT4", binaryEncodingBase64, "{}",
+			tftypes.NewValue(tftypes.List{ElementType: tftypes.DynamicPseudoType}, []tftypes.Value{}),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			elemType, ok := pgArrayElementType(c.databaseTypeName)
+			if !ok {
+				t.Fatalf("expected %q to be an array type", c.databaseTypeName)
+			}
+
+			actual, err := pgArrayValue(c.databaseTypeName, elemType, c.binaryEncoding, c.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !actual.Equal(c.expected) {
+				t.Fatalf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPGUnifyArrays(t *testing.T) {
+	number := func(f float64) tftypes.Value {
+		return tftypes.NewValue(tftypes.Number, big.NewFloat(f))
+	}
+	numberList := tftypes.List{ElementType: tftypes.Number}
+	nestedList := tftypes.List{ElementType: numberList}
+	empty := tftypes.NewValue(tftypes.List{ElementType: tftypes.DynamicPseudoType}, []tftypes.Value{})
+	null := tftypes.NewValue(tftypes.DynamicPseudoType, nil)
+
+	for name, c := range map[string]struct {
+		values       []tftypes.Value
+		expectedType tftypes.Type
+		expected     []tftypes.Value
+	}{
+		"no values": {
+			nil,
+			numberList,
+			[]tftypes.Value{},
+		},
+		"empty and null": {
+			[]tftypes.Value{empty, null},
+			numberList,
+			[]tftypes.Value{
+				tftypes.NewValue(numberList, []tftypes.Value{}),
+				tftypes.NewValue(numberList, nil),
+			},
+		},
+		"differing dimensions": {
+			[]tftypes.Value{
+				tftypes.NewValue(numberList, []tftypes.Value{number(1), number(2)}),
+				tftypes.NewValue(nestedList, []tftypes.Value{
+					tftypes.NewValue(numberList, []tftypes.Value{number(3)}),
+				}),
+				empty,
+				null,
+			},
+			nestedList,
+			[]tftypes.Value{
+				tftypes.NewValue(nestedList, []tftypes.Value{
+					tftypes.NewValue(numberList, []tftypes.Value{number(1), number(2)}),
+				}),
+				tftypes.NewValue(nestedList, []tftypes.Value{
+					tftypes.NewValue(numberList, []tftypes.Value{number(3)}),
+				}),
+				tftypes.NewValue(nestedList, []tftypes.Value{}),
+				tftypes.NewValue(nestedList, nil),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			values := append([]tftypes.Value{}, c.values...)
+			ty, err := pgUnifyArrays(values, tftypes.Number)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ty.Equal(c.expectedType) {
+				t.Fatalf("expected %s, got %s", c.expectedType, ty)
+			}
+			for i, v := range values {
+				if !v.Equal(c.expected[i]) {
+					t.Fatalf("expected %s, got %s", c.expected[i], v)
+				}
+			}
+		})
+	}
+}
+
+func TestPGIntervalScan(t *testing.T) {
+	for name, c := range map[string]struct {
+		value    interface{}
+		expected string
+	}{
+		"zero":        {"00:00:00", "PT0S"},
+		"full":        {"1 year 2 mons 3 days 04:05:06.5", "P1Y2M3DT4H5M6.5S"},
+		"date only":   {"2 years", "P2Y"},
+		"time only":   {"00:01:00", "PT1M"},
+		"negative":    {"-1 days -00:00:01", "P-1DT-1S"},
+		"iso 8601":    {"P1DT2H", "P1DT2H"},
+		"microsecond": {[]byte("00:00:00.000001"), "PT0.000001S"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			i := &pgInterval{}
+			err := i.Scan(c.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i.value == nil || *i.value != c.expected {
+				t.Fatalf("expected %q, got %v", c.expected, i.value)
+			}
+		})
+	}
+
+	i := &pgInterval{}
+	err := i.Scan(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.value != nil {
+		t.Fatalf("expected null, got %q", *i.value)
+	}
+}
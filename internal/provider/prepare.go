@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+// mysqlErrorLine matches the line of a syntax error, ie. "... near 'form t' at line 1".
+var mysqlErrorLine = regexp.MustCompile(`at line (\d+)$`)
+
+// queryErrorPosition returns the 1-based line and column of a driver specific error in the
+// query. The line is 0 if the driver did not report a position, the column is 0 if only the
+// line is known.
+func queryErrorPosition(query string, err error) (int, int) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// the position is a 1-based index of the character in the query
+		return lineAndColumn(query, int(pgErr.Position))
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if m := mysqlErrorLine.FindStringSubmatch(mysqlErr.Message); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return line, 0
+		}
+		return 0, 0
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		return int(mssqlErr.LineNo), 0
+	}
+
+	return 0, 0
+}
+
+// lineAndColumn converts a 1-based character position in the query to a line and column.
+func lineAndColumn(query string, position int) (int, int) {
+	if position < 1 {
+		return 0, 0
+	}
+
+	line, column := 1, 0
+	for i, r := range []rune(query) {
+		if i == position-1 {
+			break
+		}
+		column++
+		if r == '\n' {
+			line++
+			column = 0
+		}
+	}
+	return line, column + 1
+}
+
+// positionDetail describes the position of an error in the query, with the line of the query
+// and a marker under the column if it is known.
+func positionDetail(query string, line, column int) string {
+	lines := strings.Split(query, "\n")
+	if line < 1 || line > len(lines) {
+		return fmt.Sprintf("The error is at line %d of the query.", line)
+	}
+
+	text := strings.TrimRight(lines[line-1], "\r")
+	if column < 1 {
+		return fmt.Sprintf("The error is at line %d of the query:\n\n%s", line, text)
+	}
+
+	// tabs are kept so the marker lines up with the text
+	var marker strings.Builder
+	for i, r := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			marker.WriteRune('\t')
+			continue
+		}
+		marker.WriteRune(' ')
+	}
+	marker.WriteRune('^')
+
+	return fmt.Sprintf("The error is at line %d, column %d of the query:\n\n%s\n%s", line, column, text, marker.String())
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+func TestQueryErrorPosition(t *testing.T) {
+	query := "select 1\nfrom\tusers\nwher id = 1"
+
+	for name, c := range map[string]struct {
+		err            error
+		expectedLine   int
+		expectedColumn int
+	}{
+		"postgres":        {&pgconn.PgError{Position: 21}, 3, 1},
+		"postgres column": {fmt.Errorf("wrapped: %w", &pgconn.PgError{Position: 15}), 2, 6},
+		"postgres none":   {&pgconn.PgError{}, 0, 0},
+		"mysql": {
+			&mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near 'wher id = 1' at line 3"},
+			3, 0,
+		},
+		"mysql none": {&mysql.MySQLError{Number: 1146, Message: "Table 'test.users' doesn't exist"}, 0, 0},
+		"sqlserver":  {mssql.Error{LineNo: 2}, 2, 0},
+		"other":      {fmt.Errorf("SQL logic error: no such table: users (1)"), 0, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			line, column := queryErrorPosition(query, c.err)
+			if line != c.expectedLine || column != c.expectedColumn {
+				t.Fatalf("expected %d:%d, got %d:%d", c.expectedLine, c.expectedColumn, line, column)
+			}
+		})
+	}
+}
+
+func TestPositionDetail(t *testing.T) {
+	query := "select 1\nfrom\tusers\nwher id = 1"
+
+	for name, c := range map[string]struct {
+		line     int
+		column   int
+		expected string
+	}{
+		"column":       {3, 1, "The error is at line 3, column 1 of the query:\n\nwher id = 1\n^"},
+		"tab":          {2, 6, "The error is at line 2, column 6 of the query:\n\nfrom\tusers\n    \t^"},
+		"line":         {2, 0, "The error is at line 2 of the query:\n\nfrom\tusers"},
+		"out of range": {5, 0, "The error is at line 5 of the query."},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual := positionDetail(query, c.line, c.column)
+			if actual != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSQLServerDescribeError(t *testing.T) {
+	compileErr := mssql.Error{Number: 208, Message: "Invalid object name 'missing_table'.", LineNo: 2}
+	describeErr := mssql.Error{Number: 11501, Message: "The batch could not be analyzed because of compile errors."}
+	tempTableErr := mssql.Error{Number: 11525, Message: "The metadata could not be determined because statement 'select * from #t' uses a temp table."}
+
+	err := sqlServerDescribeError(mssql.Error{Number: describeErr.Number, All: []mssql.Error{compileErr, describeErr}})
+	if actual, ok := err.(mssql.Error); !ok || actual.Number != 208 {
+		t.Fatalf("expected the compile error, got %v", err)
+	}
+	if line, _ := queryErrorPosition("", err); line != 2 {
+		t.Fatalf("expected line 2, got %d", line)
+	}
+
+	err = sqlServerDescribeError(mssql.Error{Number: tempTableErr.Number, All: []mssql.Error{tempTableErr}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	other := fmt.Errorf("connection refused")
+	if err := sqlServerDescribeError(other); err != other {
+		t.Fatalf("expected %v, got %v", other, err)
+	}
+}
+
+func TestSQLServerParamsDeclaration(t *testing.T) {
+	actual := sqlServerParamsDeclaration([]interface{}{"a", int64(1), 1.5, true, nil})
+	expected := "@p1 nvarchar(max), @p2 bigint, @p3 float, @p4 bit, @p5 nvarchar(max)"
+	if actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual := sqlServerParamsDeclaration(nil); actual != nil {
+		t.Fatalf("expected nil, got %v", actual)
+	}
+}
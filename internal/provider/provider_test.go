@@ -1,7 +1,13 @@
 package provider
 
 import (
+	"context"
+	"fmt"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	helperresource "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	// "github.com/paultyng/terraform-provider-sql/internal/server"
 )
 
@@ -10,3 +16,128 @@ func TestServer(t *testing.T) {
 
 	// s.Test(t)
 }
+
+func TestProvider_unknownURL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the url for the "lazy" provider is not known until the data source
+			// is read during apply, so the migration needs to plan offline
+			helperresource.UnitTest(t, helperresource.TestCase{
+				ProtoV6ProviderFactories: protoV6ProviderFactories,
+				Steps: []helperresource.TestStep{
+					{
+						Config: fmt.Sprintf(`
+provider "sql" {
+	url = %[1]q
+
+	max_idle_conns = 0
+}
+
+resource "sql_migrate" "url" {
+	migration {
+		id   = "create table"
+		up   = "CREATE TABLE provider_url_test (url varchar(1000));"
+		down = "DROP TABLE provider_url_test;"
+	}
+
+	migration {
+		id   = "insert url"
+		up   = "INSERT INTO provider_url_test VALUES ('%[1]s');"
+		down = "DELETE FROM provider_url_test;"
+	}
+}
+
+data "sql_query" "url" {
+	depends_on = [sql_migrate.url]
+
+	query = "select url from provider_url_test"
+}
+
+provider "sql" {
+	alias = "lazy"
+	url   = data.sql_query.url.result[0].url
+
+	max_idle_conns    = 0
+	conn_max_lifetime = "5m"
+
+	connect_retry {
+		timeout = "30s"
+	}
+}
+
+resource "sql_migrate" "lazy" {
+	provider = sql.lazy
+
+	migration {
+		id   = "create table"
+		up   = "CREATE TABLE provider_lazy_test (id integer);"
+		down = "DROP TABLE provider_lazy_test;"
+	}
+}
+
+data "sql_query" "lazy" {
+	provider   = sql.lazy
+	depends_on = [sql_migrate.lazy]
+
+	query = "select count(*) as c from provider_lazy_test"
+}
+
+output "rowcount" {
+	value = data.sql_query.lazy.result[0].c
+}
+				`, url),
+						Check: helperresource.ComposeTestCheckFunc(
+							helperresource.TestCheckOutput("rowcount", "0"),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestProvider_validateConnectRetry(t *testing.T) {
+	p := newProvider()
+	schema := p.Schema(context.Background())
+	configType := schema.ValueType().(tftypes.Object)
+
+	retryType := configType.AttributeTypes["connect_retry"].(tftypes.Object)
+	for name, c := range map[string]struct {
+		initialBackoff string
+		expectErr      bool
+	}{
+		"valid":    {"1s", false},
+		"zero":     {"0s", true},
+		"negative": {"-1s", true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := map[string]tftypes.Value{}
+			for k, ty := range configType.AttributeTypes {
+				config[k] = tftypes.NewValue(ty, nil)
+			}
+			config["url"] = tftypes.NewValue(tftypes.String, "sqlite:///tmp/tftest.db")
+			config["connect_retry"] = tftypes.NewValue(retryType, map[string]tftypes.Value{
+				"timeout":         tftypes.NewValue(tftypes.String, nil),
+				"initial_backoff": tftypes.NewValue(tftypes.String, c.initialBackoff),
+				"max_backoff":     tftypes.NewValue(tftypes.String, nil),
+			})
+
+			diags, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasErr := diagsHaveError(diags); hasErr != c.expectErr {
+				t.Fatalf("expected error %t, got diagnostics %#v", c.expectErr, diags)
+			}
+		})
+	}
+}
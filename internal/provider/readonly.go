@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+)
+
+// beginReadOnly begins the transaction for a data source read, the caller always rolls it back.
+// Writes are rejected where the driver supports read-only transactions, otherwise they are only
+// undone by the rollback.
+func beginReadOnly(ctx context.Context, db dbBeginner, driver driverName) (*queryTx, error) {
+	switch driver {
+	case driverSQLServer, driverSQLite:
+		// read-only transactions are rejected by the sqlserver driver and ignored by the sqlite
+		// driver, PRAGMA query_only is not used for sqlite as it would remain set on the pooled
+		// connection if the transaction was cancelled before it was reset
+		return db.BeginTx(ctx, nil)
+	}
+
+	// BEGIN READ ONLY for postgres, START TRANSACTION READ ONLY for mysql
+	return db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+}
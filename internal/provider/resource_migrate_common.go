@@ -45,6 +45,9 @@ func (r *resourceMigrateCommon) Create(ctx context.Context, planned map[string]t
 
 	err = migration.Up(ctx, r.db, plannedMigrations, nil)
 	if err != nil {
+		if diags := stoppedDiagnostics(err); diags != nil {
+			return nil, diags, nil
+		}
 		return nil, nil, err
 	}
 
@@ -64,6 +67,9 @@ func (r *resourceMigrateCommon) Update(ctx context.Context, planned map[string]t
 
 	err = migration.Up(ctx, r.db, plannedMigrations, priorCompleteMigrations)
 	if err != nil {
+		if diags := stoppedDiagnostics(err); diags != nil {
+			return nil, diags, nil
+		}
 		return nil, nil, err
 	}
 
@@ -78,6 +84,9 @@ func (r *resourceMigrateCommon) Destroy(ctx context.Context, prior map[string]tf
 
 	err = migration.Down(ctx, r.db, nil, priorCompleteMigrations)
 	if err != nil {
+		if diags := stoppedDiagnostics(err); diags != nil {
+			return diags, nil
+		}
 		return nil, err
 	}
 
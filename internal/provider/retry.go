@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+// connectRetry configures retrying the initial connection to the database, this is useful
+// when the database is created in the same apply and may still be starting up.
+type connectRetry struct {
+	Timeout        time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+const (
+	defaultConnectRetryTimeout        = time.Minute
+	defaultConnectRetryInitialBackoff = time.Second
+	defaultConnectRetryMaxBackoff     = 30 * time.Second
+)
+
+// Do calls f until it succeeds or the timeout elapses, doubling the wait between attempts
+// up to MaxBackoff. Only errors that may resolve on their own (see isRetryableConnectError) are
+// retried, others are returned immediately. A nil connectRetry calls f once.
+func (r *connectRetry) Do(ctx context.Context, f func(context.Context) error) error {
+	if r == nil {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	backoff := r.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if !isRetryableConnectError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("giving up after %d attempt(s) in %s: %w", attempt, r.Timeout, err)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > r.MaxBackoff {
+			backoff = r.MaxBackoff
+		}
+	}
+}
+
+// isRetryableConnectError determines if a connection error is transient, ie. the server is not
+// listening yet or is still starting up. Errors such as authentication failures, unknown
+// databases or malformed connection strings are not retried.
+func isRetryableConnectError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		// dial errors (ie. connection refused), timeouts and DNS failures
+		return true
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		// the server closed the connection during the handshake
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// cannot_connect_now, ie. "the database system is starting up"
+		return pgErr.Code == "57P03"
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		// login failed as the server is in script upgrade mode, which happens while it starts
+		return mssqlErr.Number == 18401
+	}
+
+	return false
+}
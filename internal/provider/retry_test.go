@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+)
+
+func TestConnectRetry(t *testing.T) {
+	errNotReady := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	t.Run("nil", func(t *testing.T) {
+		var r *connectRetry
+		attempts := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			return errNotReady
+		})
+		if !errors.Is(err, errNotReady) {
+			t.Fatalf("expected %s, got %v", errNotReady, err)
+		}
+		if attempts != 1 {
+			t.Fatalf("expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("eventually succeeds", func(t *testing.T) {
+		r := &connectRetry{
+			Timeout:        time.Minute,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     4 * time.Millisecond,
+		}
+		attempts := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			if attempts < 5 {
+				return errNotReady
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempts != 5 {
+			t.Fatalf("expected 5 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		r := &connectRetry{
+			Timeout:        20 * time.Millisecond,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     2 * time.Millisecond,
+		}
+		attempts := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			return errNotReady
+		})
+		if !errors.Is(err, errNotReady) {
+			t.Fatalf("expected %s, got %v", errNotReady, err)
+		}
+		if attempts < 2 {
+			t.Fatalf("expected multiple attempts, got %d", attempts)
+		}
+	})
+	t.Run("not retryable", func(t *testing.T) {
+		errAuth := &pgconn.PgError{Code: "28P01", Message: "password authentication failed"}
+		r := &connectRetry{
+			Timeout:        time.Minute,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+		}
+		attempts := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			return fmt.Errorf("unable to connect: %w", errAuth)
+		})
+		if !errors.Is(err, errAuth) {
+			t.Fatalf("expected %s, got %v", errAuth, err)
+		}
+		if attempts != 1 {
+			t.Fatalf("expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestIsRetryableConnectError(t *testing.T) {
+	for name, c := range map[string]struct {
+		err      error
+		expected bool
+	}{
+		"connection refused": {fmt.Errorf("ping: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true},
+		"dns":                {&net.DNSError{Err: "no such host", Name: "db", IsNotFound: true}, true},
+		"starting up":        {&pgconn.PgError{Code: "57P03"}, true},
+		"auth":               {&pgconn.PgError{Code: "28P01"}, false},
+		"unknown database":   {&pgconn.PgError{Code: "3D000"}, false},
+		"malformed":          {errors.New("cannot parse `postgres://%`: invalid URL escape"), false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := isRetryableConnectError(c.err); actual != c.expected {
+				t.Fatalf("expected %t, got %t", c.expected, actual)
+			}
+		})
+	}
+}
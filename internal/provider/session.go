@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// sessionInitConnector runs the session initialization statements on every new connection
+// before it is added to the pool, so session state (search_path, role, etc) applies to all
+// statements regardless of which pooled connection they use.
+type sessionInitConnector struct {
+	driver.Connector
+	statements []string
+	// resetClearsSession is set for drivers that clear the session state when a pooled
+	// connection is reused (ie. sqlserver), the statements are then run again after each reset
+	resetClearsSession bool
+}
+
+var _ driver.Connector = (*sessionInitConnector)(nil)
+
+func (c *sessionInitConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = runSessionInit(ctx, conn, c.statements)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if c.resetClearsSession {
+		return &sessionInitConn{
+			Conn:       conn,
+			statements: c.statements,
+		}, nil
+	}
+	return conn, nil
+}
+
+// runSessionInit runs the session initialization statements on the connection.
+func runSessionInit(ctx context.Context, conn driver.Conn, statements []string) error {
+	for _, stmt := range statements {
+		err := execConn(ctx, conn, stmt)
+		if err != nil {
+			return fmt.Errorf("unable to run session_init statement %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// sessionInitConn runs the session initialization statements again after the driver resets
+// the session of a pooled connection. The optional driver interfaces are passed through to
+// the connection.
+type sessionInitConn struct {
+	driver.Conn
+	statements []string
+}
+
+var (
+	_ driver.SessionResetter    = (*sessionInitConn)(nil)
+	_ driver.Validator          = (*sessionInitConn)(nil)
+	_ driver.Pinger             = (*sessionInitConn)(nil)
+	_ driver.ConnPrepareContext = (*sessionInitConn)(nil)
+	_ driver.ConnBeginTx        = (*sessionInitConn)(nil)
+	_ driver.ExecerContext      = (*sessionInitConn)(nil)
+	_ driver.QueryerContext     = (*sessionInitConn)(nil)
+	_ driver.NamedValueChecker  = (*sessionInitConn)(nil)
+)
+
+func (c *sessionInitConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		err := resetter.ResetSession(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := runSessionInit(ctx, c.Conn, c.statements)
+	if err != nil {
+		// the pool discards the connection, the error is reported when a new one is opened
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *sessionInitConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *sessionInitConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sessionInitConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *sessionInitConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.ReadOnly || opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, fmt.Errorf("the driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *sessionInitConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sessionInitConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sessionInitConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// execConn executes a statement on a driver connection, using the fastest interface the
+// driver implements.
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+		return err
+	}
+
+	// fallback for drivers that do not implement StmtExecContext
+	_, err = stmt.Exec(nil)
+	return err
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionInitConnector(t *testing.T) {
+	url := "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "tftest.db"))
+
+	p := &provider{
+		sessionInit: []string{
+			"PRAGMA foreign_keys = ON",
+			"CREATE TEMP TABLE session_init (id INTEGER)",
+		},
+	}
+	db, err := p.connect(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(2)
+
+	ctx := context.Background()
+
+	// hold two connections open to ensure the statements run on each of them
+	for i := 0; i < 2; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		var fk int
+		err = conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&fk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fk != 1 {
+			t.Fatalf("expected foreign_keys to be 1, got %d", fk)
+		}
+
+		// temp tables are per connection
+		_, err = conn.ExecContext(ctx, "INSERT INTO session_init (id) VALUES (1)")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("error", func(t *testing.T) {
+		p := &provider{
+			sessionInit: []string{"NOT VALID SQL"},
+		}
+		db, err := p.connect(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		err = db.PingContext(ctx)
+		if err == nil {
+			t.Fatal("expected error but got none")
+		}
+	})
+}
+
+// resetSessionConn is a driver connection whose session state is cleared when it is reset,
+// like go-mssqldb's reset of pooled connections.
+type resetSessionConn struct {
+	initialized bool
+	resets      *int
+}
+
+func (c *resetSessionConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *resetSessionConn) Close() error                        { return nil }
+func (c *resetSessionConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c *resetSessionConn) ResetSession(context.Context) error {
+	*c.resets++
+	c.initialized = false
+	return nil
+}
+
+func (c *resetSessionConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	switch query {
+	case "init":
+		c.initialized = true
+	case "check":
+		if !c.initialized {
+			return nil, errors.New("the session is not initialized")
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+type resetSessionConnector struct {
+	resets int
+}
+
+func (c *resetSessionConnector) Connect(context.Context) (driver.Conn, error) {
+	return &resetSessionConn{resets: &c.resets}, nil
+}
+
+func (c *resetSessionConnector) Driver() driver.Driver { return nil }
+
+func TestSessionInitConnector_reset(t *testing.T) {
+	inner := &resetSessionConnector{}
+	db := sql.OpenDB(&sessionInitConnector{
+		Connector:          inner,
+		statements:         []string{"init"},
+		resetClearsSession: true,
+	})
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := db.ExecContext(ctx, "check")
+		if err != nil {
+			t.Fatalf("query %d: %s", i+1, err)
+		}
+	}
+	if inner.resets == 0 {
+		t.Fatal("expected the pooled connection to be reset")
+	}
+}
+
+func TestSessionInitConnector_sqlserver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping long test")
+	}
+
+	for _, server := range testServers {
+		if server.ServerType != "sqlserver" {
+			continue
+		}
+
+		t.Run(server.ServerType, func(t *testing.T) {
+			url, _, err := server.URL()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := &provider{
+				sessionInit: []string{"SET NOCOUNT ON"},
+			}
+			db, err := p.connect(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			// a single connection, so the second query reuses (and resets) it
+			db.SetMaxOpenConns(1)
+
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				// 512 is the NOCOUNT bit of @@OPTIONS
+				var nocount int
+				err = db.QueryRowContext(ctx, "SELECT @@OPTIONS & 512").Scan(&nocount)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if nocount != 512 {
+					t.Fatalf("query %d: expected NOCOUNT to be on", i+1)
+				}
+			}
+		})
+	}
+}
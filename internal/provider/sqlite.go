@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// sqliteTypeForDeclType maps a SQLite declared column type to a Terraform type using the
+// type affinity rules, see https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+//
+// SQLite is dynamically typed, so the scan type reported by the driver depends on the value
+// in the current row, the declared type is the only stable typing for a column. Columns
+// without a declared type (expressions, etc) return false.
+func sqliteTypeForDeclType(declType string) (tftypes.Type, reflect.Type, bool) {
+	declType = strings.ToUpper(declType)
+
+	switch declType {
+	case "":
+		return nil, nil, false
+	case "BOOL", "BOOLEAN":
+		return tftypes.Bool, reflect.TypeOf((*sql.NullBool)(nil)).Elem(), true
+	case "DATE", "DATETIME", "TIMESTAMP", "TIME":
+		// the driver will parse these in to time.Time if the value is text in a known format,
+		// scanning to a string will format those as RFC3339 and also handles unparsable values
+		return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), true
+	case "JSON":
+		// JSON is stored as text
+		return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), true
+	}
+
+	switch {
+	case strings.Contains(declType, "INT"):
+		return tftypes.Number, reflect.TypeOf((*sql.NullInt64)(nil)).Elem(), true
+	case strings.Contains(declType, "CHAR"),
+		strings.Contains(declType, "CLOB"),
+		strings.Contains(declType, "TEXT"):
+		return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), true
+	case strings.Contains(declType, "BLOB"):
+		return nil, nil, false
+	case strings.Contains(declType, "REAL"),
+		strings.Contains(declType, "FLOA"),
+		strings.Contains(declType, "DOUB"),
+		strings.Contains(declType, "NUMERIC"),
+		strings.Contains(declType, "DECIMAL"):
+		// numbers may be stored as integers, reals or text in these columns
+		return tftypes.Number, reflect.TypeOf((*sqlNumeric)(nil)).Elem(), true
+	}
+
+	// any other declared type has NUMERIC affinity, but is usually used for text (ie. uuid or
+	// string), a string accepts a value of any storage class
+	return tftypes.String, reflect.TypeOf((*sql.NullString)(nil)).Elem(), true
+}
+
+// sqliteNumberFunctions are the SQL functions and aggregates that always return a number or
+// NULL, whatever the type of their arguments.
+var sqliteNumberFunctions = map[string]bool{
+	"abs": true, "avg": true, "changes": true, "count": true, "cume_dist": true, "dense_rank": true,
+	"instr": true, "julianday": true, "last_insert_rowid": true, "length": true, "ntile": true,
+	"octet_length": true, "percent_rank": true, "random": true, "rank": true, "round": true,
+	"row_number": true, "sign": true, "sum": true, "total": true, "total_changes": true,
+	"unicode": true, "unixepoch": true,
+}
+
+// sqliteOp is an instruction of a compiled SQLite program, see https://www.sqlite.org/opcode.html
+type sqliteOp struct {
+	opcode     string
+	p1, p2, p3 int64
+	p4         string
+}
+
+// sqliteResultTypes infers the types of the result columns of a query from its compiled
+// program, as SQLite does not report a type for expressions and the scan type of a column
+// without a declared type depends on the value in the current row. A column is only typed as a
+// number if every instruction that can set it produces a number (arithmetic, count(*), etc),
+// the type of other columns is nil. The types are nil if the query is not a single statement
+// or cannot be explained.
+func sqliteResultTypes(ctx context.Context, db dbQueryer, query string, args []interface{}) []tftypes.Type {
+	if len(splitStatements(driverSQLite, query)) != 1 {
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var program []sqliteOp
+	for rows.Next() {
+		var (
+			addr      int64
+			op        sqliteOp
+			p4, p5, c interface{}
+		)
+		err = rows.Scan(&addr, &op.opcode, &op.p1, &op.p2, &op.p3, &p4, &p5, &c)
+		if err != nil {
+			return nil
+		}
+		if s, ok := p4.(string); ok {
+			op.p4 = s
+		}
+		program = append(program, op)
+	}
+	if rows.Err() != nil {
+		return nil
+	}
+
+	return sqliteProgramResultTypes(program)
+}
+
+// sqliteProgramResultTypes infers the types of the registers of the program's ResultRow
+// instructions, see sqliteResultTypes.
+func sqliteProgramResultTypes(program []sqliteOp) []tftypes.Type {
+	// the sources of each register, a source is either a type, nil for NULL, a register it
+	// is copied from or sqliteUnknownSource
+	sources := map[int64][]interface{}{}
+	set := func(reg int64, source interface{}) {
+		sources[reg] = append(sources[reg], source)
+	}
+
+	var results [][2]int64
+	for _, op := range program {
+		switch op.opcode {
+		case "ResultRow":
+			results = append(results, [2]int64{op.p1, op.p2})
+		case "Integer", "Int64", "Real", "Rowid", "Sequence", "Count":
+			set(op.p2, tftypes.Number)
+		case "String8", "String":
+			set(op.p2, tftypes.String)
+		case "Null":
+			for reg := op.p2; reg <= op.p3 || reg == op.p2; reg++ {
+				set(reg, nil)
+			}
+		case "Add", "Subtract", "Multiply", "Divide", "Remainder", "BitAnd", "BitOr", "ShiftLeft", "ShiftRight":
+			set(op.p3, tftypes.Number)
+		case "Concat":
+			set(op.p3, tftypes.String)
+		case "Copy":
+			for i := int64(0); i <= op.p3; i++ {
+				set(op.p2+i, sqliteRegister(op.p1+i))
+			}
+		case "SCopy":
+			set(op.p2, sqliteRegister(op.p1))
+		case "Move":
+			for i := int64(0); i < op.p3; i++ {
+				set(op.p2+i, sqliteRegister(op.p1+i))
+			}
+		case "AggFinal":
+			set(op.p1, sqliteFunctionType(op.p4))
+		case "AggValue", "Function", "PureFunc":
+			set(op.p3, sqliteFunctionType(op.p4))
+		case "Init", "Goto", "Halt", "Transaction", "TableLock", "Noop", "Explain", "ColumnsUsed",
+			"OpenRead", "OpenEphemeral", "OpenAutoindex", "OpenPseudo", "OpenDup", "SorterOpen", "Close",
+			"Rewind", "Last", "Next", "Prev", "SorterSort", "SorterNext", "SorterInsert", "IdxInsert",
+			"Compare", "Jump", "If", "IfNot", "IsNull", "NotNull", "Eq", "Ne", "Lt", "Le", "Gt", "Ge",
+			"Return", "Once", "CollSeq", "AggStep", "AggStep1", "AggInverse", "ReleaseReg", "CursorHint":
+			// these do not set any register read by a ResultRow
+		case "Column", "MakeRecord":
+			set(op.p3, sqliteUnknownSource{})
+		case "SorterData", "RowData", "Variable", "Blob", "BeginSubrtn":
+			set(op.p2, sqliteUnknownSource{})
+		case "Gosub", "IfPos", "DecrJumpZero", "Cast", "AddImm", "MustBeInt", "RealAffinity", "SoftNull":
+			set(op.p1, sqliteUnknownSource{})
+		case "Affinity":
+			for i := int64(0); i < op.p2; i++ {
+				set(op.p1+i, sqliteUnknownSource{})
+			}
+		default:
+			// the operands of other instructions may be registers they set
+			set(op.p1, sqliteUnknownSource{})
+			set(op.p2, sqliteUnknownSource{})
+			set(op.p3, sqliteUnknownSource{})
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	var resolve func(reg int64, seen map[int64]bool) (tftypes.Type, bool)
+	resolve = func(reg int64, seen map[int64]bool) (tftypes.Type, bool) {
+		if seen[reg] {
+			// a copy cycle adds no sources
+			return nil, true
+		}
+		seen[reg] = true
+
+		var ty tftypes.Type
+		for _, source := range sources[reg] {
+			var sourceType tftypes.Type
+			switch source := source.(type) {
+			case nil:
+				continue
+			case tftypes.Type:
+				sourceType = source
+			case sqliteRegister:
+				var ok bool
+				sourceType, ok = resolve(int64(source), seen)
+				if !ok {
+					return nil, false
+				}
+				if sourceType == nil {
+					continue
+				}
+			default:
+				return nil, false
+			}
+			if ty != nil && !ty.Equal(sourceType) {
+				return nil, false
+			}
+			ty = sourceType
+		}
+		return ty, true
+	}
+
+	var types []tftypes.Type
+	for i, result := range results {
+		if i > 0 && result[1] != int64(len(types)) {
+			return nil
+		}
+		for col := int64(0); col < result[1]; col++ {
+			ty, ok := resolve(result[0]+col, map[int64]bool{})
+			if !ok || ty == nil || !ty.Is(tftypes.Number) {
+				ty = nil
+			}
+			if i == 0 {
+				types = append(types, ty)
+				continue
+			}
+			if types[col] == nil || ty == nil {
+				// compound queries may set the column from different instructions
+				types[col] = nil
+			}
+		}
+	}
+	return types
+}
+
+// sqliteRegister is a register a register is copied from.
+type sqliteRegister int64
+
+// sqliteUnknownSource is the source of a register that is set by an instruction whose result
+// is not known.
+type sqliteUnknownSource struct{}
+
+// sqliteFunctionType returns the type of a function from its P4 operand (ie. `count(0)`), or
+// sqliteUnknownSource if it is not known.
+func sqliteFunctionType(p4 string) interface{} {
+	name := strings.ToLower(p4)
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	if sqliteNumberFunctions[name] {
+		return tftypes.Number
+	}
+	return sqliteUnknownSource{}
+}
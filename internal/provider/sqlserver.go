@@ -4,6 +4,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
 )
 
 type sqlServerUniqueIdentifier [16]byte
@@ -59,3 +62,66 @@ func (u *sqlServerUniqueIdentifier) ToTerraform5Value() (interface{}, error) {
 	s := fmt.Sprintf("%X-%X-%X-%X-%X", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
 	return &s, nil
 }
+
+// sqlServerNullUniqueIdentifier is a nullable sqlServerUniqueIdentifier.
+type sqlServerNullUniqueIdentifier struct {
+	UniqueIdentifier sqlServerUniqueIdentifier
+	Valid            bool
+}
+
+func (u *sqlServerNullUniqueIdentifier) Scan(v interface{}) error {
+	if v == nil {
+		u.UniqueIdentifier, u.Valid = sqlServerUniqueIdentifier{}, false
+		return nil
+	}
+	u.Valid = true
+	return u.UniqueIdentifier.Scan(v)
+}
+
+func (u *sqlServerNullUniqueIdentifier) ToTerraform5Value() (interface{}, error) {
+	if u == nil || !u.Valid {
+		return nil, nil
+	}
+	return u.UniqueIdentifier.ToTerraform5Value()
+}
+
+// sqlServerParamsDeclaration declares the parameters of a query bound by bindParameters (or
+// passed through to the driver as `@p1`, `@p2`, etc) for sp_describe_first_result_set.
+func sqlServerParamsDeclaration(args []interface{}) interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	params := make([]string, 0, len(args))
+	for i, arg := range args {
+		ty := "nvarchar(max)"
+		switch arg.(type) {
+		case int64:
+			ty = "bigint"
+		case float64:
+			ty = "float"
+		case bool:
+			ty = "bit"
+		}
+		params = append(params, fmt.Sprintf("@p%d %s", i+1, ty))
+	}
+	return strings.Join(params, ", ")
+}
+
+// sqlServerDescribeError returns the error of the query from the errors of
+// sp_describe_first_result_set. The errors in the 11500 range only describe why the result
+// could not be determined, if there are only those (ie. the batch uses a temporary table) the
+// query is not invalid.
+func sqlServerDescribeError(err error) error {
+	var mssqlErr mssql.Error
+	if !errors.As(err, &mssqlErr) {
+		return err
+	}
+
+	for _, e := range mssqlErr.All {
+		if e.Number < 11500 || e.Number >= 11600 {
+			return e
+		}
+	}
+	return nil
+}
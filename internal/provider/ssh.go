@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshHandshakeTimeout bounds the SSH handshake when the context has no deadline, so a host that
+// stalls does not hang the connection (and the locks held while connecting).
+const sshHandshakeTimeout = 30 * time.Second
+
+// sshTunnelSettings configures dialing the database through an SSH jump host.
+type sshTunnelSettings struct {
+	Host       string
+	User       string
+	PrivateKey string
+	HostKey    string
+}
+
+// sshTunnel dials connections through an SSH jump host, the SSH connection is
+// established on first use and shared by all database connections.
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHTunnel(settings *sshTunnelSettings) (*sshTunnel, error) {
+	if settings == nil {
+		return nil, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(settings.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private_key: %w", err)
+	}
+
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(settings.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse host_key: %w", err)
+	}
+
+	addr := settings.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	return &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            settings.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+		},
+	}, nil
+}
+
+func (t *sshTunnel) sshClient(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to SSH host %q: %w", t.addr, err)
+	}
+
+	c, chans, reqs, err := sshHandshake(ctx, conn, t.addr, t.config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to establish SSH connection to %q: %w", t.addr, err)
+	}
+
+	t.client = ssh.NewClient(c, chans, reqs)
+	go func(client *ssh.Client) {
+		// if the SSH connection drops, reconnect on the next dial
+		_ = client.Wait()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.client == client {
+			t.client = nil
+		}
+	}(t.client)
+
+	return t.client, nil
+}
+
+// sshHandshake establishes the SSH connection on conn, the handshake is bounded by the deadline
+// of the context (or sshHandshakeTimeout) and the connection is closed if the context is done.
+func sshHandshake(ctx context.Context, conn net.Conn, addr string, config *ssh.ClientConfig) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(sshHandshakeTimeout)
+	}
+	err := conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	close(done)
+	<-stopped
+	if err == nil && ctx.Err() != nil {
+		// the context was done as the handshake completed, the connection may be closed
+		c.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, nil, ctxErr
+		}
+		return nil, nil, nil, err
+	}
+
+	// the deadline only applies to the handshake
+	err = conn.SetDeadline(time.Time{})
+	if err != nil {
+		c.Close()
+		return nil, nil, nil, err
+	}
+	return c, chans, reqs, nil
+}
+
+// DialContext dials addr from the SSH host.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	result := make(chan dialResult, 1)
+	go func() {
+		conn, err := client.Dial(network, addr)
+		result <- dialResult{conn, err}
+	}()
+
+	select {
+	case r := <-result:
+		if r.err != nil {
+			return nil, fmt.Errorf("unable to dial %q through SSH host %q: %w", addr, t.addr, r.err)
+		}
+		return r.conn, nil
+	case <-ctx.Done():
+		go func() {
+			if r := <-result; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the SSH connection if it was opened.
+func (t *sshTunnel) Close() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == nil {
+		return nil
+	}
+
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
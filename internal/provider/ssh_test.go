@@ -0,0 +1,277 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSSHServer starts an in-process SSH server that only supports port forwarding
+// (direct-tcpip channels) and returns its address and host key.
+func startTestSSHServer(t *testing.T, authorized ssh.PublicKey) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tftest" && string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChan := range chans {
+					if newChan.ChannelType() != "direct-tcpip" {
+						newChan.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					err := ssh.Unmarshal(newChan.ExtraData(), &target)
+					if err != nil {
+						newChan.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					dst, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+					if err != nil {
+						newChan.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					ch, chReqs, err := newChan.Accept()
+					if err != nil {
+						dst.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chReqs)
+					go func() {
+						defer ch.Close()
+						defer dst.Close()
+						go io.Copy(dst, ch)
+						io.Copy(ch, dst)
+					}()
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String(), hostSigner.PublicKey()
+}
+
+func startTestEchoServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestSSHTunnel(t *testing.T) {
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSSHPub, err := ssh.NewPublicKey(&clientKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+
+	sshAddr, hostKey := startTestSSHServer(t, clientSSHPub)
+	echoAddr := startTestEchoServer(t)
+
+	t.Run("dial", func(t *testing.T) {
+		tunnel, err := newSSHTunnel(&sshTunnelSettings{
+			Host:       sshAddr,
+			User:       "tftest",
+			PrivateKey: privateKey,
+			HostKey:    string(ssh.MarshalAuthorizedKey(hostKey)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tunnel.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		conn, err := tunnel.DialContext(ctx, "tcp", echoAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(conn, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "hello" {
+			t.Fatalf("expected %q, got %q", "hello", buf)
+		}
+	})
+
+	t.Run("host key mismatch", func(t *testing.T) {
+		otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherSSHPub, err := ssh.NewPublicKey(otherPub)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tunnel, err := newSSHTunnel(&sshTunnelSettings{
+			Host:       sshAddr,
+			User:       "tftest",
+			PrivateKey: privateKey,
+			HostKey:    string(ssh.MarshalAuthorizedKey(otherSSHPub)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tunnel.Close()
+
+		_, err = tunnel.DialContext(context.Background(), "tcp", echoAddr)
+		if err == nil {
+			t.Fatal("expected error but got none")
+		}
+	})
+
+	t.Run("stalled handshake", func(t *testing.T) {
+		// accepts connections but never responds to the handshake
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		tunnel, err := newSSHTunnel(&sshTunnelSettings{
+			Host:       l.Addr().String(),
+			User:       "tftest",
+			PrivateKey: privateKey,
+			HostKey:    string(ssh.MarshalAuthorizedKey(hostKey)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tunnel.Close()
+
+		for name, newCtx := range map[string]func() (context.Context, context.CancelFunc){
+			"deadline": func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+			"canceled": func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(100*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				ctx, cancel := newCtx()
+				defer cancel()
+
+				errs := make(chan error, 1)
+				go func() {
+					_, err := tunnel.DialContext(ctx, "tcp", echoAddr)
+					errs <- err
+				}()
+
+				select {
+				case err := <-errs:
+					if err == nil {
+						t.Fatal("expected error but got none")
+					}
+				case <-time.After(10 * time.Second):
+					t.Fatal("the handshake did not stop with the context")
+				}
+
+				// the tunnel is not locked by the stalled handshake
+				closed := make(chan error, 1)
+				go func() { closed <- tunnel.Close() }()
+				select {
+				case <-closed:
+				case <-time.After(10 * time.Second):
+					t.Fatal("closing the tunnel did not return")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// varsFromValue reads the `vars` attribute, an object or map of values to render in the query.
+func varsFromValue(v tftypes.Value) (map[string]tftypes.Value, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+
+	ty := v.Type()
+	if !ty.Is(tftypes.Map{}) && !ty.Is(tftypes.Object{}) {
+		return nil, fmt.Errorf("vars must be an object or a map, got %s", ty)
+	}
+
+	var vars map[string]tftypes.Value
+	err := v.As(&vars)
+	if err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// sqlDialect is how values are quoted when rendering a query.
+type sqlDialect struct {
+	driver driverName
+	// noBackslashEscapes is set when the MySQL NO_BACKSLASH_ESCAPES SQL mode is enabled, a
+	// backslash is then not an escape character in string literals
+	noBackslashEscapes bool
+}
+
+// renderQuery replaces the `${name}` placeholders in the query with the value of the variable
+// quoted as a literal for the dialect, `${name:identifier}` is quoted as an identifier instead.
+// Lists are rendered as a comma separated list of literals, for use in an `IN (...)` clause.
+// `$${` is rendered as a literal `${`.
+func renderQuery(dialect sqlDialect, query string, vars map[string]tftypes.Value) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(query, "${")
+		if i < 0 {
+			b.WriteString(query)
+			return b.String(), nil
+		}
+
+		if i > 0 && query[i-1] == '$' {
+			b.WriteString(query[:i-1])
+			b.WriteString("${")
+			query = query[i+2:]
+			continue
+		}
+
+		end := strings.Index(query[i:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated template placeholder %q", query[i:])
+		}
+
+		b.WriteString(query[:i])
+		placeholder := query[i+2 : i+end]
+		query = query[i+end+1:]
+
+		name, format := placeholder, "literal"
+		if colon := strings.LastIndex(placeholder, ":"); colon >= 0 {
+			name, format = placeholder[:colon], placeholder[colon+1:]
+		}
+		name = strings.TrimSpace(name)
+
+		v, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("variable %q is not set in vars", name)
+		}
+
+		var (
+			s   string
+			err error
+		)
+		switch strings.TrimSpace(format) {
+		case "literal":
+			s, err = quoteLiteralValue(dialect, v)
+		case "identifier":
+			var ident string
+			if v.IsNull() || !v.Type().Is(tftypes.String) {
+				return "", fmt.Errorf("variable %q must be a string to be used as an identifier", name)
+			}
+			err = v.As(&ident)
+			s = quoteIdentifier(dialect.driver, ident)
+		default:
+			return "", fmt.Errorf("unsupported format %q for variable %q, expected `literal` or `identifier`", format, name)
+		}
+		if err != nil {
+			return "", fmt.Errorf("variable %q: %w", name, err)
+		}
+		b.WriteString(s)
+	}
+}
+
+// quoteIdentifier quotes an identifier for the driver, each part of a qualified name (ie.
+// `schema.table`) is quoted separately.
+func quoteIdentifier(driver driverName, ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		switch driver {
+		case driverMySQL:
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		case driverSQLServer:
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		default:
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteLiteral quotes a string literal for the dialect.
+func quoteLiteral(dialect sqlDialect, s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	switch dialect.driver {
+	case driverMySQL:
+		if dialect.noBackslashEscapes {
+			return "'" + s + "'"
+		}
+		return "'" + strings.ReplaceAll(s, `\`, `\\`) + "'"
+	case driverSQLServer:
+		return "N'" + s + "'"
+	}
+	return "'" + s + "'"
+}
+
+// quoteLiteralValue renders a value as a literal for the dialect.
+func quoteLiteralValue(dialect sqlDialect, v tftypes.Value) (string, error) {
+	if !v.IsKnown() {
+		return "", fmt.Errorf("value is unknown")
+	}
+	if v.IsNull() {
+		return "NULL", nil
+	}
+
+	switch ty := v.Type(); {
+	case ty.Is(tftypes.String):
+		var s string
+		err := v.As(&s)
+		if err != nil {
+			return "", err
+		}
+		return quoteLiteral(dialect, s), nil
+	case ty.Is(tftypes.Number):
+		n := &big.Float{}
+		err := v.As(&n)
+		if err != nil {
+			return "", err
+		}
+		if n.Sign() < 0 {
+			// parenthesized so it is not combined with a preceding operator, ie. `10-${n}`
+			return "(" + n.Text('f', -1) + ")", nil
+		}
+		return n.Text('f', -1), nil
+	case ty.Is(tftypes.Bool):
+		var b bool
+		err := v.As(&b)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case dialect.driver == driverSQLServer && b:
+			return "1", nil
+		case dialect.driver == driverSQLServer:
+			return "0", nil
+		case b:
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case ty.Is(tftypes.List{}), ty.Is(tftypes.Tuple{}), ty.Is(tftypes.Set{}):
+		var elems []tftypes.Value
+		err := v.As(&elems)
+		if err != nil {
+			return "", err
+		}
+		if len(elems) == 0 {
+			return "", fmt.Errorf("an empty list cannot be rendered")
+		}
+		literals := make([]string, 0, len(elems))
+		for _, elem := range elems {
+			if elem.IsKnown() && !elem.IsNull() && !isPrimitiveType(elem.Type()) {
+				return "", fmt.Errorf("lists can only contain strings, numbers and bools")
+			}
+			s, err := quoteLiteralValue(dialect, elem)
+			if err != nil {
+				return "", err
+			}
+			literals = append(literals, s)
+		}
+		return strings.Join(literals, ", "), nil
+	default:
+		return "", fmt.Errorf("%s values cannot be rendered", ty)
+	}
+}
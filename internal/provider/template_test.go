@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRenderQuery(t *testing.T) {
+	vars := map[string]tftypes.Value{
+		"name":   tftypes.NewValue(tftypes.String, "it's"),
+		"path":   tftypes.NewValue(tftypes.String, `a\b`),
+		"id":     tftypes.NewValue(tftypes.Number, big.NewFloat(1.5)),
+		"n":      tftypes.NewValue(tftypes.Number, big.NewFloat(-5)),
+		"active": tftypes.NewValue(tftypes.Bool, true),
+		"null":   tftypes.NewValue(tftypes.String, nil),
+		"table":  tftypes.NewValue(tftypes.String, `my"schema.users`),
+		"ids": tftypes.NewValue(tftypes.List{ElementType: tftypes.Number}, []tftypes.Value{
+			tftypes.NewValue(tftypes.Number, big.NewFloat(1)),
+			tftypes.NewValue(tftypes.Number, big.NewFloat(-2)),
+		}),
+		"empty":  tftypes.NewValue(tftypes.List{ElementType: tftypes.Number}, []tftypes.Value{}),
+		"object": tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{}}, map[string]tftypes.Value{}),
+	}
+
+	for name, c := range map[string]struct {
+		dialect   sqlDialect
+		query     string
+		expected  string
+		expectErr bool
+	}{
+		"no placeholders":            {sqlDialect{driver: driverPGX}, "select 1", "select 1", false},
+		"pgx string":                 {sqlDialect{driver: driverPGX}, "select ${name}", "select 'it''s'", false},
+		"mysql string":               {sqlDialect{driver: driverMySQL}, "select ${name}, ${path}", `select 'it''s', 'a\\b'`, false},
+		"sqlserver string":           {sqlDialect{driver: driverSQLServer}, "select ${name}", "select N'it''s'", false},
+		"number":                     {sqlDialect{driver: driverPGX}, "select ${id}", "select 1.5", false},
+		"negative number":            {sqlDialect{driver: driverPGX}, "select 10-${n}", "select 10-(-5)", false},
+		"mysql no backslash escapes": {sqlDialect{driver: driverMySQL, noBackslashEscapes: true}, "select ${name}, ${path}", `select 'it''s', 'a\b'`, false},
+		"bool":                       {sqlDialect{driver: driverSQLite}, "select ${active}", "select TRUE", false},
+		"sqlserver bool":             {sqlDialect{driver: driverSQLServer}, "select ${active}", "select 1", false},
+		"null":                       {sqlDialect{driver: driverPGX}, "select ${null}", "select NULL", false},
+		"list":                       {sqlDialect{driver: driverPGX}, "select 1 where 1 in (${ids})", "select 1 where 1 in (1, (-2))", false},
+		"explicit literal":           {sqlDialect{driver: driverPGX}, "select ${ name : literal }", "select 'it''s'", false},
+		"escaped":                    {sqlDialect{driver: driverPGX}, "select '$${name}', ${id}", "select '${name}', 1.5", false},
+
+		"pgx identifier":       {sqlDialect{driver: driverPGX}, "select * from ${table:identifier}", `select * from "my""schema"."users"`, false},
+		"mysql identifier":     {sqlDialect{driver: driverMySQL}, "select * from ${table:identifier}", "select * from `my\"schema`.`users`", false},
+		"sqlserver identifier": {sqlDialect{driver: driverSQLServer}, "select * from ${table:identifier}", `select * from [my"schema].[users]`, false},
+
+		"not set":               {sqlDialect{driver: driverPGX}, "select ${missing}", "", true},
+		"unterminated":          {sqlDialect{driver: driverPGX}, "select ${name", "", true},
+		"unsupported format":    {sqlDialect{driver: driverPGX}, "select ${name:raw}", "", true},
+		"identifier not string": {sqlDialect{driver: driverPGX}, "select ${id:identifier}", "", true},
+		"empty list":            {sqlDialect{driver: driverPGX}, "select ${empty}", "", true},
+		"object":                {sqlDialect{driver: driverPGX}, "select ${object}", "", true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := renderQuery(c.dialect, c.query, vars)
+			if c.expectErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != c.expected {
+				t.Fatalf("expected query %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
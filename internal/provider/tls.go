@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// tlsAttributes are the provider attributes used to build the client TLS configuration.
+var tlsAttributes = []string{"tls_ca_cert", "tls_client_cert", "tls_client_key", "tls_server_name"}
+
+// tlsSettings is the PEM encoded client TLS configuration shared by all drivers.
+type tlsSettings struct {
+	CACert     string
+	ClientCert string
+	ClientKey  string
+	ServerName string
+}
+
+// Config builds a tls.Config from the settings, a nil tlsSettings returns a nil config.
+func (t *tlsSettings) Config() (*tls.Config, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName: t.ServerName,
+	}
+
+	if t.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(t.CACert)) {
+			return nil, fmt.Errorf("unable to parse tls_ca_cert, no PEM encoded certificates found")
+		}
+		cfg.RootCAs = pool
+	}
+
+	switch {
+	case t.ClientCert != "" && t.ClientKey != "":
+		cert, err := tls.X509KeyPair([]byte(t.ClientCert), []byte(t.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse tls_client_cert and tls_client_key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	case t.ClientCert != "" || t.ClientKey != "":
+		return nil, fmt.Errorf("tls_client_cert and tls_client_key must be set together")
+	}
+
+	return cfg, nil
+}
+
+// serverTLSConfig returns a copy of the config with the server name defaulted to host.
+func serverTLSConfig(cfg *tls.Config, host string) *tls.Config {
+	cfg = cfg.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	return cfg
+}
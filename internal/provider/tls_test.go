@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testPEMKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tftest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return string(certPEM), string(keyPEM)
+}
+
+func TestTLSSettingsConfig(t *testing.T) {
+	cert, key := testPEMKeyPair(t)
+
+	for name, c := range map[string]struct {
+		settings  *tlsSettings
+		expectErr bool
+	}{
+		"nil":         {nil, false},
+		"ca":          {&tlsSettings{CACert: cert}, false},
+		"client":      {&tlsSettings{CACert: cert, ClientCert: cert, ClientKey: key, ServerName: "db"}, false},
+		"invalid ca":  {&tlsSettings{CACert: "not a cert"}, true},
+		"missing key": {&tlsSettings{ClientCert: cert}, true},
+		"invalid key": {&tlsSettings{ClientCert: cert, ClientKey: cert}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := c.settings.Config()
+			if c.expectErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.settings == nil {
+				if cfg != nil {
+					t.Fatal("expected nil config")
+				}
+				return
+			}
+			if cfg.ServerName != c.settings.ServerName {
+				t.Fatalf("expected server name %q, got %q", c.settings.ServerName, cfg.ServerName)
+			}
+			if (c.settings.CACert != "") != (cfg.RootCAs != nil) {
+				t.Fatalf("unexpected root CAs")
+			}
+			if (c.settings.ClientCert != "") != (len(cfg.Certificates) == 1) {
+				t.Fatalf("unexpected client certificates")
+			}
+		})
+	}
+}
+
+func TestConnector_tls(t *testing.T) {
+	cert, key := testPEMKeyPair(t)
+
+	p := &provider{
+		tls: &tlsSettings{
+			CACert:     cert,
+			ClientCert: cert,
+			ClientKey:  key,
+		},
+	}
+
+	for _, url := range []string{
+		"postgres://postgres@localhost:5432/tftest",
+		"mysql://root@tcp(localhost:3306)/mysql",
+		"sqlserver://sa@localhost:1433",
+	} {
+		t.Run(url, func(t *testing.T) {
+			driver, dsn, err := driverForURL(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = p.connector(driver, dsn)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("sqlite", func(t *testing.T) {
+		_, err := p.connector(driverSQLite, "tftest.db")
+		if err == nil {
+			t.Fatal("expected error but got none")
+		}
+	})
+}
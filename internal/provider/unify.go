@@ -0,0 +1,250 @@
+package provider
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// unifyTypes returns a type both types can be converted to, tftypes.DynamicPseudoType is used
+// for a type that is not yet known (ie. a null value). Objects are unified by
+// combining their attributes (missing attributes are null), lists by unifying their
+// element types, tuples of the same length by unifying each element type and differing
+// primitives are converted to strings.
+func unifyTypes(a, b tftypes.Type) (tftypes.Type, error) {
+	return unifyTypesWith(a, b, true)
+}
+
+// unifyElementTypes is unifyTypes without converting differing primitives to strings, it
+// returns an error for types that would lose their type if combined (ie. a number and a
+// string).
+func unifyElementTypes(a, b tftypes.Type) (tftypes.Type, error) {
+	return unifyTypesWith(a, b, false)
+}
+
+func unifyTypesWith(a, b tftypes.Type, convertPrimitives bool) (tftypes.Type, error) {
+	switch {
+	case a.Is(tftypes.DynamicPseudoType):
+		return b, nil
+	case b.Is(tftypes.DynamicPseudoType):
+		return a, nil
+	case a.Equal(b):
+		return a, nil
+	case a.Is(tftypes.Object{}) && b.Is(tftypes.Object{}):
+		aAttrs := a.(tftypes.Object).AttributeTypes
+		bAttrs := b.(tftypes.Object).AttributeTypes
+		attrTypes := make(map[string]tftypes.Type, len(aAttrs))
+		for k, ty := range aAttrs {
+			attrTypes[k] = ty
+		}
+		for k, ty := range bAttrs {
+			existing, ok := attrTypes[k]
+			if !ok {
+				attrTypes[k] = ty
+				continue
+			}
+			unified, err := unifyTypesWith(existing, ty, convertPrimitives)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q: %w", k, err)
+			}
+			attrTypes[k] = unified
+		}
+		return tftypes.Object{AttributeTypes: attrTypes}, nil
+	case a.Is(tftypes.List{}) && b.Is(tftypes.List{}):
+		elemType, err := unifyTypesWith(a.(tftypes.List).ElementType, b.(tftypes.List).ElementType, convertPrimitives)
+		if err != nil {
+			return nil, err
+		}
+		return tftypes.List{ElementType: elemType}, nil
+	case a.Is(tftypes.Tuple{}) && b.Is(tftypes.Tuple{}):
+		aElems := a.(tftypes.Tuple).ElementTypes
+		bElems := b.(tftypes.Tuple).ElementTypes
+		if len(aElems) != len(bElems) {
+			return nil, fmt.Errorf("incompatible types %s and %s", a, b)
+		}
+		elemTypes := make([]tftypes.Type, len(aElems))
+		for i := range aElems {
+			unified, err := unifyTypesWith(aElems[i], bElems[i], convertPrimitives)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elemTypes[i] = unified
+		}
+		return tftypes.Tuple{ElementTypes: elemTypes}, nil
+	case convertPrimitives && isPrimitiveType(a) && isPrimitiveType(b):
+		return tftypes.String, nil
+	}
+
+	return nil, fmt.Errorf("incompatible types %s and %s", a, b)
+}
+
+func isPrimitiveType(ty tftypes.Type) bool {
+	return ty.Is(tftypes.String) || ty.Is(tftypes.Number) || ty.Is(tftypes.Bool)
+}
+
+// resolveType replaces any types that could not be determined (ie. only null values or empty
+// lists were seen) with the fallback type. For lists, the element type of a list fallback is
+// used for the elements.
+func resolveType(ty, fallback tftypes.Type) tftypes.Type {
+	switch {
+	case ty.Is(tftypes.DynamicPseudoType):
+		return fallback
+	case ty.Is(tftypes.List{}):
+		elemFallback := fallback
+		if fallback.Is(tftypes.List{}) {
+			elemFallback = fallback.(tftypes.List).ElementType
+		}
+		return tftypes.List{ElementType: resolveType(ty.(tftypes.List).ElementType, elemFallback)}
+	case ty.Is(tftypes.Tuple{}):
+		elemFallback := fallback
+		if fallback.Is(tftypes.List{}) {
+			elemFallback = fallback.(tftypes.List).ElementType
+		}
+		elemTypes := make([]tftypes.Type, 0, len(ty.(tftypes.Tuple).ElementTypes))
+		for _, elemType := range ty.(tftypes.Tuple).ElementTypes {
+			elemTypes = append(elemTypes, resolveType(elemType, elemFallback))
+		}
+		return tftypes.Tuple{ElementTypes: elemTypes}
+	case ty.Is(tftypes.Object{}):
+		attrTypes := map[string]tftypes.Type{}
+		for k, attrType := range ty.(tftypes.Object).AttributeTypes {
+			attrTypes[k] = resolveType(attrType, fallback)
+		}
+		return tftypes.Object{AttributeTypes: attrTypes}
+	}
+	return ty
+}
+
+// convertValue converts a value to a type returned by unifyTypes.
+func convertValue(v tftypes.Value, ty tftypes.Type) (tftypes.Value, error) {
+	if ty.Is(tftypes.DynamicPseudoType) || v.Type().Equal(ty) {
+		return v, nil
+	}
+	if v.IsNull() {
+		return tftypes.NewValue(ty, nil), nil
+	}
+
+	switch {
+	case ty.Is(tftypes.String):
+		switch {
+		case v.Type().Is(tftypes.Number):
+			n := &big.Float{}
+			err := v.As(&n)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			return tftypes.NewValue(tftypes.String, n.Text('f', -1)), nil
+		case v.Type().Is(tftypes.Bool):
+			var b bool
+			err := v.As(&b)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			return tftypes.NewValue(tftypes.String, strconv.FormatBool(b)), nil
+		}
+	case ty.Is(tftypes.List{}) && v.Type().Is(tftypes.List{}):
+		var elems []tftypes.Value
+		err := v.As(&elems)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		elemType := ty.(tftypes.List).ElementType
+		for i, elem := range elems {
+			elems[i], err = convertValue(elem, elemType)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+		}
+		return tftypes.NewValue(ty, elems), nil
+	case ty.Is(tftypes.Tuple{}) && v.Type().Is(tftypes.Tuple{}):
+		var elems []tftypes.Value
+		err := v.As(&elems)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		elemTypes := ty.(tftypes.Tuple).ElementTypes
+		if len(elems) != len(elemTypes) {
+			break
+		}
+		for i, elem := range elems {
+			elems[i], err = convertValue(elem, elemTypes[i])
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+		}
+		return tftypes.NewValue(ty, elems), nil
+	case ty.Is(tftypes.Object{}) && v.Type().Is(tftypes.Object{}):
+		var attrs map[string]tftypes.Value
+		err := v.As(&attrs)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		converted := map[string]tftypes.Value{}
+		for k, attrType := range ty.(tftypes.Object).AttributeTypes {
+			attr, ok := attrs[k]
+			if !ok {
+				converted[k] = tftypes.NewValue(attrType, nil)
+				continue
+			}
+			converted[k], err = convertValue(attr, attrType)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+		}
+		return tftypes.NewValue(ty, converted), nil
+	}
+
+	return tftypes.Value{}, fmt.Errorf("unable to convert %s to %s", v.Type(), ty)
+}
+
+// unifyDecodedColumns determines a single type for each decoded column across all the rows,
+// as values (JSON documents, multi-dimensional arrays, etc) may differ in shape, and converts
+// the values in the rows to that type. The column type is used for any types that could not
+// be determined from the values.
+func unifyDecodedColumns(columns []resultColumn, rows []map[string]tftypes.Value) error {
+	for i, col := range columns {
+		if col.decode == nil {
+			continue
+		}
+
+		if col.unify != nil {
+			values := make([]tftypes.Value, 0, len(rows))
+			for _, row := range rows {
+				values = append(values, row[col.name])
+			}
+			ty, err := col.unify(values)
+			if err != nil {
+				return fmt.Errorf("unable to determine a type for column %q: %w", col.name, err)
+			}
+			for j, row := range rows {
+				row[col.name] = values[j]
+			}
+			columns[i].ty = ty
+			continue
+		}
+
+		var ty tftypes.Type = tftypes.DynamicPseudoType
+		for _, row := range rows {
+			var err error
+			ty, err = unifyTypes(ty, row[col.name].Type())
+			if err != nil {
+				return fmt.Errorf("unable to determine a type for column %q: %w", col.name, err)
+			}
+		}
+		ty = resolveType(ty, col.ty)
+
+		for _, row := range rows {
+			v, err := convertValue(row[col.name], ty)
+			if err != nil {
+				return fmt.Errorf("unable to convert column %q: %w", col.name, err)
+			}
+			row[col.name] = v
+		}
+
+		columns[i].ty = ty
+	}
+
+	return nil
+}
@@ -12,3 +12,7 @@ type Provider interface {
 	Validate(ctx context.Context, config map[string]tftypes.Value) (diags []*tfprotov6.Diagnostic, err error)
 	Configure(ctx context.Context, config map[string]tftypes.Value) (diags []*tfprotov6.Diagnostic, err error)
 }
+
+type ProviderStopper interface {
+	Stop(ctx context.Context) error
+}
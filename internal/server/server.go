@@ -258,8 +258,18 @@ func (s *Server) ConfigureProvider(ctx context.Context, req *tfprotov6.Configure
 }
 
 func (s *Server) StopProvider(ctx context.Context, req *tfprotov6.StopProviderRequest) (*tfprotov6.StopProviderResponse, error) {
-	// TODO: close/reopen? db connection
-	panic("not implemented")
+	stopper, ok := s.p.(ProviderStopper)
+	if !ok {
+		return &tfprotov6.StopProviderResponse{}, nil
+	}
+
+	err := stopper.Stop(ctx)
+	if err != nil {
+		return &tfprotov6.StopProviderResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	return &tfprotov6.StopProviderResponse{}, nil
 }
 
 // ResourceServer methods